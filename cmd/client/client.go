@@ -29,7 +29,10 @@ func main() {
 
 	log.Printf("Mailer Client: %s\n", client)
 
-	if err := client.Send(context.Background(), model.NewMailRequest().From("mailer@vibioh.fr").As("Client").To("customer@vibioh.fr").Template("hello")); err != nil {
+	messageID, err := client.Send(context.Background(), model.NewMailRequest().From("mailer@vibioh.fr").As("Client").To("customer@vibioh.fr").Template("hello"))
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	log.Printf("Message-ID: %s\n", messageID)
 }
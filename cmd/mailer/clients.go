@@ -11,6 +11,7 @@ import (
 	"github.com/ViBiOh/httputils/v4/pkg/pprof"
 	"github.com/ViBiOh/httputils/v4/pkg/request"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	"github.com/ViBiOh/mailer/pkg/mjml"
 )
 
 type clients struct {
@@ -19,6 +20,7 @@ type clients struct {
 	health    *health.Service
 
 	amqp *amqp.Client
+	mjml mjml.Service
 }
 
 func newClients(ctx context.Context, config configuration) (clients, error) {
@@ -43,7 +45,12 @@ func newClients(ctx context.Context, config configuration) (clients, error) {
 		return output, fmt.Errorf("amqp: %w", err)
 	}
 
-	output.health = health.New(ctx, config.health)
+	output.mjml, err = mjml.New(config.mjml, output.telemetry.MeterProvider(), output.telemetry.TracerProvider())
+	if err != nil {
+		return output, fmt.Errorf("mjml: %w", err)
+	}
+
+	output.health = health.New(ctx, config.health, output.mjml.Ping)
 
 	return output, nil
 }
@@ -15,9 +15,14 @@ import (
 	"github.com/ViBiOh/httputils/v4/pkg/pprof"
 	"github.com/ViBiOh/httputils/v4/pkg/server"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	"github.com/ViBiOh/mailer/pkg/bounce"
+	"github.com/ViBiOh/mailer/pkg/images"
 	"github.com/ViBiOh/mailer/pkg/mailer"
 	"github.com/ViBiOh/mailer/pkg/mjml"
 	"github.com/ViBiOh/mailer/pkg/smtp"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
 )
 
 type configuration struct {
@@ -34,9 +39,14 @@ type configuration struct {
 	amqp        *amqp.Config
 	amqphandler *amqphandler.Config
 
-	smtp   *smtp.Config
-	mjml   *mjml.Config
-	mailer *mailer.Config
+	smtp        *smtp.Config
+	mjml        *mjml.Config
+	mailer      *mailer.Config
+	suppression *suppression.Config
+	unsubscribe *unsubscribe.Config
+	bounce      *bounce.Config
+	store       *store.Config
+	images      *images.Config
 }
 
 func newConfig() configuration {
@@ -57,9 +67,14 @@ func newConfig() configuration {
 		amqp:        amqp.Flags(fs, "amqp"),
 		amqphandler: amqphandler.Flags(fs, "amqp", flags.NewOverride("Exchange", "mailer"), flags.NewOverride("Queue", "mailer")),
 
-		smtp:   smtp.Flags(fs, "smtp"),
-		mjml:   mjml.Flags(fs, "mjml"),
-		mailer: mailer.Flags(fs, ""),
+		smtp:        smtp.Flags(fs, "smtp"),
+		mjml:        mjml.Flags(fs, "mjml"),
+		mailer:      mailer.Flags(fs, ""),
+		suppression: suppression.Flags(fs, "suppression"),
+		unsubscribe: unsubscribe.Flags(fs, "unsubscribe"),
+		bounce:      bounce.Flags(fs, "bounce"),
+		store:       store.Flags(fs, "store"),
+		images:      images.Flags(fs, "images"),
 	}
 
 	_ = fs.Parse(os.Args[1:])
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"os"
+	_ "time/tzdata"
 
 	"github.com/ViBiOh/httputils/v4/pkg/alcotest"
 	"github.com/ViBiOh/httputils/v4/pkg/amqp"
@@ -15,6 +17,10 @@ func main() {
 	config := newConfig()
 	alcotest.DoAndExit(config.alcotest)
 
+	if config.mailer.ValidateOnly {
+		os.Exit(validate(config))
+	}
+
 	ctx := context.Background()
 
 	clients, err := newClients(ctx, config)
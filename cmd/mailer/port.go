@@ -10,11 +10,27 @@ import (
 func newPort(clients clients, services services) http.Handler {
 	mux := http.NewServeMux()
 
-	handler := httphandler.New(services.mailer, clients.telemetry.TracerProvider())
+	handler := httphandler.New(services.mailer, services.suppression, services.unsubscribe, services.bounce, services.store, clients.telemetry.TracerProvider())
 
 	mux.HandleFunc("GET /fixtures/{fixture...}", handler.HandleFixture)
 	mux.HandleFunc("GET /render/{template...}", handler.HandlerTemplate)
 	mux.HandleFunc("POST /render/{template...}", handler.HandlerSend)
+	mux.HandleFunc("GET /templates/validate", handler.HandleValidate)
+	mux.HandleFunc("GET /templates/reload", handler.HandleReloadStatus)
+	mux.HandleFunc("POST /templates/reload", handler.HandleReload)
+	mux.HandleFunc("GET /templates", handler.HandleTemplateList)
+	mux.HandleFunc("GET /templates/{name}", handler.HandleTemplateGet)
+	mux.HandleFunc("PUT /templates/{name}", handler.HandleTemplatePut)
+	mux.HandleFunc("DELETE /templates/{name}", handler.HandleTemplateDelete)
+	mux.HandleFunc("POST /templates/{name}/rollback", handler.HandleTemplateRollback)
+	mux.HandleFunc("GET /suppressions", handler.HandleSuppressionList)
+	mux.HandleFunc("POST /suppressions", handler.HandleSuppressionAdd)
+	mux.HandleFunc("GET /suppressions/{email}", handler.HandleSuppressionGet)
+	mux.HandleFunc("DELETE /suppressions/{email}", handler.HandleSuppressionDelete)
+	mux.HandleFunc("GET /bounces", handler.HandleBounceList)
+	mux.HandleFunc("POST /bounces", handler.HandleBounce)
+	mux.HandleFunc("GET /unsubscribe", handler.HandleUnsubscribe)
+	mux.HandleFunc("POST /unsubscribe", handler.HandleUnsubscribePost)
 	mux.HandleFunc("GET /", handler.HandleRoot)
 
 	return httputils.Handler(
@@ -8,9 +8,13 @@ import (
 	"github.com/ViBiOh/httputils/v4/pkg/cors"
 	"github.com/ViBiOh/httputils/v4/pkg/owasp"
 	"github.com/ViBiOh/httputils/v4/pkg/server"
+	"github.com/ViBiOh/mailer/pkg/bounce"
+	"github.com/ViBiOh/mailer/pkg/images"
 	"github.com/ViBiOh/mailer/pkg/mailer"
-	"github.com/ViBiOh/mailer/pkg/mjml"
 	"github.com/ViBiOh/mailer/pkg/smtp"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
 )
 
 type services struct {
@@ -19,6 +23,10 @@ type services struct {
 	cors   cors.Service
 
 	amqpHandler *amqphandler.Service
+	suppression suppression.Service
+	unsubscribe unsubscribe.Service
+	bounce      bounce.Service
+	store       store.Service
 	mailer      mailer.Service
 }
 
@@ -35,10 +43,30 @@ func newServices(config configuration, clients clients) (services, error) {
 	output.owasp = owasp.New(config.owasp)
 	output.cors = cors.New(config.cors)
 
-	mjmlService := mjml.New(config.mjml, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
 	smtpService := smtp.New(config.smtp, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
 
-	output.mailer = mailer.New(config.mailer, mjmlService, smtpService, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
+	output.suppression, err = suppression.New(config.suppression)
+	if err != nil {
+		return output, fmt.Errorf("suppression: %w", err)
+	}
+
+	output.unsubscribe = unsubscribe.New(config.unsubscribe)
+	output.bounce, err = bounce.New(config.bounce, config.smtp.BounceAddress, output.suppression)
+	if err != nil {
+		return output, fmt.Errorf("bounce: %w", err)
+	}
+
+	imagesService := images.New(config.images, clients.telemetry.TracerProvider())
+
+	output.store, err = store.New(config.store)
+	if err != nil {
+		return output, fmt.Errorf("store: %w", err)
+	}
+
+	output.mailer, err = mailer.New(config.mailer, clients.mjml, smtpService, output.suppression, output.unsubscribe, output.store, imagesService, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
+	if err != nil {
+		return output, fmt.Errorf("mailer: %w", err)
+	}
 
 	output.amqpHandler, err = amqphandler.New(config.amqphandler, clients.amqp, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider(), output.mailer.AmqpHandler)
 	if err != nil {
@@ -49,6 +77,7 @@ func newServices(config configuration, clients clients) (services, error) {
 }
 
 func (s services) Start(ctx context.Context) {
+	go s.mailer.Start(ctx)
 	go s.amqpHandler.Start(ctx)
 }
 
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/ViBiOh/httputils/v4/pkg/logger"
+	"github.com/ViBiOh/mailer/pkg/mailer"
+	"github.com/ViBiOh/mailer/pkg/mjml"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
+)
+
+// validate prints the templates validation report and returns the exit code
+func validate(config configuration) int {
+	ctx := context.Background()
+
+	logger.Init(ctx, config.logger)
+
+	// Loading error is part of the report
+	mailerService, _ := mailer.New(config.mailer, mjml.Service{}, nil, nil, unsubscribe.New(config.unsubscribe), store.Service{}, nil, nil, nil)
+
+	report := mailerService.Validate()
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(report); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "encode report", slog.Any("error", err))
+		return 2
+	}
+
+	if !report.Valid {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,201 @@
+package bounce
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/flags"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/jsonfile"
+	"github.com/ViBiOh/mailer/pkg/secret"
+	"github.com/ViBiOh/mailer/pkg/smtp"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+)
+
+type suppressor interface {
+	Add(ctx context.Context, entry suppression.Entry) error
+}
+
+type Record struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	Email      string    `json:"email"`
+	Status     string    `json:"status"`
+	Diagnostic string    `json:"diagnostic,omitempty"`
+	Hard       uint      `json:"hard"`
+	Soft       uint      `json:"soft"`
+}
+
+type Service struct {
+	suppressionService suppressor
+	records            map[string]Record
+	mutex              *sync.RWMutex
+	bounceAddress      string
+	file               string
+	secret             secret.Secret
+	softLimit          uint
+}
+
+type Config struct {
+	File      string
+	Secret    string
+	SoftLimit uint
+}
+
+func Flags(fs *flag.FlagSet, prefix string) *Config {
+	var config Config
+
+	flags.New("SoftLimit", "Number of soft bounces before suppressing recipient, 0 to disable").Prefix(prefix).DocPrefix("bounce").UintVar(fs, &config.SoftLimit, 3, nil)
+	flags.New("File", "Bounce records file, kept in memory if empty").Prefix(prefix).DocPrefix("bounce").StringVar(fs, &config.File, "", nil)
+	flags.New("Secret", "Shared secret required as Bearer token on bounce endpoints, disabled if empty").Prefix(prefix).DocPrefix("bounce").StringVar(fs, &config.Secret, "", nil)
+
+	return &config
+}
+
+func New(config *Config, bounceAddress string, suppressionService suppressor) (Service, error) {
+	service := Service{
+		suppressionService: suppressionService,
+		bounceAddress:      bounceAddress,
+		file:               config.File,
+		secret:             secret.New("bounce", config.Secret),
+		softLimit:          config.SoftLimit,
+		records:            make(map[string]Record),
+		mutex:              &sync.RWMutex{},
+	}
+
+	records, err := jsonfile.Load[Record](config.File)
+	if err != nil {
+		return service, fmt.Errorf("load: %w", err)
+	}
+
+	for _, record := range records {
+		service.records[record.Email] = record
+	}
+
+	return service, nil
+}
+
+// Authorize checks the shared secret of the bounce endpoints
+func (s Service) Authorize(token string) error {
+	return s.secret.Authorize(token)
+}
+
+func (s Service) List() []Record {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	output := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		output = append(output, record)
+	}
+
+	slices.SortFunc(output, func(a, b Record) int {
+		return strings.Compare(a.Email, b.Email)
+	})
+
+	return output
+}
+
+// Handle parses the delivery status notification and records bounces of each recipient
+func (s Service) Handle(ctx context.Context, reader io.Reader) ([]RecipientStatus, error) {
+	notification, err := Parse(reader)
+	if err != nil {
+		return nil, httpModel.WrapInvalid(fmt.Errorf("parse notification: %w", err))
+	}
+
+	verpRecipient := s.verpRecipient(notification)
+
+	for index, status := range notification.Recipients {
+		// VERP identifies exactly the address we sent to, whereas the notification may contain forwarded address
+		if (len(verpRecipient) != 0 && len(notification.Recipients) == 1) || len(status.Recipient) == 0 {
+			status.Recipient = verpRecipient
+			notification.Recipients[index] = status
+		}
+
+		if len(status.Recipient) == 0 {
+			slog.LogAttrs(ctx, slog.LevelWarn, "bounce without recipient", slog.String("status", status.Status))
+			continue
+		}
+
+		if err := s.record(ctx, status); err != nil {
+			return notification.Recipients, fmt.Errorf("record `%s`: %w", status.Recipient, err)
+		}
+	}
+
+	return notification.Recipients, nil
+}
+
+// verpRecipient finds the recipient encoded in the address the notification has been delivered to
+func (s Service) verpRecipient(notification Notification) string {
+	if len(s.bounceAddress) == 0 {
+		return ""
+	}
+
+	for _, header := range []string{"X-Original-To", "Delivered-To", "To"} {
+		addresses, err := notification.Headers.AddressList(header)
+		if err != nil {
+			continue
+		}
+
+		for _, address := range addresses {
+			if recipient, ok := smtp.DecodeVERP(s.bounceAddress, address.Address); ok {
+				return recipient
+			}
+		}
+	}
+
+	return ""
+}
+
+func (s Service) record(ctx context.Context, status RecipientStatus) error {
+	if !status.Hard() && !status.Soft() {
+		return nil
+	}
+
+	email := strings.ToLower(status.Recipient)
+
+	s.mutex.Lock()
+
+	record := s.records[email]
+	record.Email = email
+	record.Status = status.Status
+	record.Diagnostic = status.Diagnostic
+	record.UpdatedAt = time.Now()
+
+	if status.Hard() {
+		record.Hard++
+	} else {
+		record.Soft++
+	}
+
+	records := maps.Clone(s.records)
+	records[email] = record
+
+	if err := jsonfile.Save(s.file, slices.Collect(maps.Values(records))); err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("save: %w", err)
+	}
+
+	s.records[email] = record
+
+	s.mutex.Unlock()
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "bounce received", slog.String("email", email), slog.String("status", status.Status), slog.Bool("hard", status.Hard()))
+
+	if !status.Hard() && (s.softLimit == 0 || record.Soft < s.softLimit) {
+		return nil
+	}
+
+	return s.suppressionService.Add(ctx, suppression.Entry{
+		Email:  email,
+		Reason: suppression.ReasonBounce,
+		Detail: fmt.Sprintf("%s %s", status.Status, status.Diagnostic),
+	})
+}
@@ -0,0 +1,62 @@
+package bounce
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/suppression"
+)
+
+type noopSuppressor struct{}
+
+func (noopSuppressor) Add(context.Context, suppression.Entry) error {
+	return nil
+}
+
+func TestPersistence(t *testing.T) {
+	t.Parallel()
+
+	config := Config{
+		File:      filepath.Join(t.TempDir(), "bounces.json"),
+		SoftLimit: 3,
+	}
+
+	instance, err := New(&config, "", noopSuppressor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := instance.record(context.Background(), RecipientStatus{Recipient: "Bob@localhost", Action: "delayed", Status: "4.2.2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := New(&config, "", noopSuppressor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := reloaded.List()
+	if len(got) != 1 || got[0].Email != "bob@localhost" || got[0].Soft != 2 {
+		t.Errorf("List() = %+v, want bob@localhost with 2 soft bounces", got)
+	}
+}
+
+func TestRecordSaveError(t *testing.T) {
+	t.Parallel()
+
+	instance, err := New(&Config{File: filepath.Join(t.TempDir(), "missing", "bounces.json")}, "", noopSuppressor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.record(context.Background(), RecipientStatus{Recipient: "bob@localhost", Action: "failed", Status: "5.1.1"}); err == nil {
+		t.Error("record() = nil, want error")
+	}
+
+	if got := instance.List(); len(got) != 0 {
+		t.Errorf("List() = %+v, want nothing", got)
+	}
+}
@@ -0,0 +1,151 @@
+package bounce
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+const deliveryStatusType = "message/delivery-status"
+
+var ErrNoDeliveryStatus = errors.New("no delivery status found")
+
+// Notification is a parsed delivery status notification, as described in RFC 3464
+type Notification struct {
+	Headers    mail.Header
+	EnvelopeID string
+	Recipients []RecipientStatus
+}
+
+type RecipientStatus struct {
+	Recipient  string `json:"recipient"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+	Diagnostic string `json:"diagnostic,omitempty"`
+}
+
+// Hard checks if status is a permanent failure
+func (rs RecipientStatus) Hard() bool {
+	return rs.Action == "failed" && strings.HasPrefix(rs.Status, "5")
+}
+
+// Soft checks if status is a transient failure
+func (rs RecipientStatus) Soft() bool {
+	return rs.Action == "delayed" || (rs.Action == "failed" && !strings.HasPrefix(rs.Status, "5"))
+}
+
+func Parse(reader io.Reader) (Notification, error) {
+	var output Notification
+
+	message, err := mail.ReadMessage(reader)
+	if err != nil {
+		return output, fmt.Errorf("read message: %w", err)
+	}
+
+	output.Headers = message.Header
+
+	status, err := findDeliveryStatus(message.Header.Get("Content-Type"), message.Body)
+	if err != nil {
+		return output, err
+	}
+
+	return parseDeliveryStatus(output, status)
+}
+
+func findDeliveryStatus(contentType string, body io.Reader) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("parse content-type `%s`: %w", contentType, err)
+	}
+
+	if mediaType == deliveryStatusType {
+		return io.ReadAll(body)
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, ErrNoDeliveryStatus
+	}
+
+	reader := multipart.NewReader(body, params["boundary"])
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, ErrNoDeliveryStatus
+			}
+
+			return nil, fmt.Errorf("read part: %w", err)
+		}
+
+		partType := part.Header.Get("Content-Type")
+		if len(partType) == 0 {
+			continue
+		}
+
+		status, err := findDeliveryStatus(partType, part)
+		if err == nil {
+			return status, nil
+		}
+
+		if !errors.Is(err, ErrNoDeliveryStatus) {
+			return nil, err
+		}
+	}
+}
+
+func parseDeliveryStatus(output Notification, content []byte) (Notification, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(content)))
+
+	messageFields, err := reader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return output, fmt.Errorf("read per-message fields: %w", err)
+	}
+
+	output.EnvelopeID = messageFields.Get("Original-Envelope-Id")
+
+	for {
+		fields, err := reader.ReadMIMEHeader()
+		if len(fields) != 0 {
+			recipient := addressValue(fields.Get("Original-Recipient"))
+			if len(recipient) == 0 {
+				recipient = addressValue(fields.Get("Final-Recipient"))
+			}
+
+			output.Recipients = append(output.Recipients, RecipientStatus{
+				Recipient:  recipient,
+				Action:     strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+				Status:     strings.TrimSpace(fields.Get("Status")),
+				Diagnostic: typedValue(fields.Get("Diagnostic-Code")),
+			})
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return output, nil
+			}
+
+			return output, fmt.Errorf("read per-recipient fields: %w", err)
+		}
+	}
+}
+
+// typedValue removes the type of field, e.g. `smtp; 550 unknown user`
+func typedValue(value string) string {
+	if _, content, ok := strings.Cut(value, ";"); ok {
+		return strings.TrimSpace(content)
+	}
+
+	return strings.TrimSpace(value)
+}
+
+func addressValue(value string) string {
+	return strings.Trim(typedValue(value), "<>")
+}
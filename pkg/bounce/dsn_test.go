@@ -0,0 +1,70 @@
+package bounce
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		file           string
+		content        string
+		wantEnvelopeID string
+		want           []RecipientStatus
+		wantErr        error
+	}{
+		"not a report": {
+			content: "From: bob@example.com\r\nContent-Type: text/plain\r\n\r\nHello\r\n",
+			wantErr: ErrNoDeliveryStatus,
+		},
+		"report": {
+			file:           "testdata/dsn.eml",
+			wantEnvelopeID: "0123456789",
+			want: []RecipientStatus{
+				{
+					Recipient:  "bob@example.com",
+					Action:     "failed",
+					Status:     "5.1.1",
+					Diagnostic: "550 5.1.1 <bob@example.com>: Recipient address rejected: User unknown",
+				},
+				{
+					Recipient:  "alice@example.com",
+					Action:     "delayed",
+					Status:     "4.4.1",
+					Diagnostic: "421 4.4.1 Connection timed out",
+				},
+			},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			content := testCase.content
+			if len(testCase.file) != 0 {
+				raw, err := os.ReadFile(testCase.file)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				content = string(raw)
+			}
+
+			got, gotErr := Parse(strings.NewReader(content))
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("Parse() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+
+			if got.EnvelopeID != testCase.wantEnvelopeID || !reflect.DeepEqual(got.Recipients, testCase.want) {
+				t.Errorf("Parse() = (`%s`, %+v), want (`%s`, %+v)", got.EnvelopeID, got.Recipients, testCase.wantEnvelopeID, testCase.want)
+			}
+		})
+	}
+}
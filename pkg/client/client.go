@@ -5,11 +5,15 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/ViBiOh/flags"
 	amqpclient "github.com/ViBiOh/httputils/v4/pkg/amqp"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
 	"github.com/ViBiOh/httputils/v4/pkg/request"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
 	"github.com/ViBiOh/mailer/pkg/model"
@@ -99,20 +103,27 @@ func (s Service) amqpEnabled() bool {
 	return s.amqpClient != nil && s.amqpClient.Enabled()
 }
 
-func (s Service) Send(ctx context.Context, mailRequest model.MailRequest) (err error) {
+// Send sends the mail request and returns its message id, for threading replies. With AMQP, it's only known when given or when the sender is.
+func (s Service) Send(ctx context.Context, mailRequest model.MailRequest) (messageID string, err error) {
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "send")
 	defer end(&err)
 
 	if !s.Enabled() {
-		return ErrNotEnabled
+		return "", ErrNotEnabled
 	}
 
-	if err := mailRequest.Check(); err != nil {
-		return err
+	// Sender can be filled by the mailer from the metadata of the template
+	if err := mailRequest.CheckPartial(); err != nil {
+		return "", err
 	}
 
 	if s.amqpEnabled() {
-		return s.amqpClient.PublishJSON(ctx, mailRequest, s.exchange, "")
+		// AMQP is asynchronous so the mailer can't give it back, it's generated here when the sender's domain is known
+		if len(mailRequest.MessageID) == 0 && len(mailRequest.FromEmail) != 0 {
+			mailRequest = mailRequest.WithMessageID(model.NewMessageID(mailRequest.FromEmail))
+		}
+
+		return mailRequest.MessageID, s.amqpClient.PublishJSON(ctx, mailRequest, s.exchange, "")
 	}
 
 	return s.httpSend(ctx, mailRequest)
@@ -124,16 +135,51 @@ func (s Service) Close(ctx context.Context) {
 	}
 }
 
-func (s Service) httpSend(ctx context.Context, mail model.MailRequest) error {
+// httpSend returns the message id generated by the mailer, once the sender is known from the metadata of the template
+func (s Service) httpSend(ctx context.Context, mail model.MailRequest) (string, error) {
 	query := url.Values{
-		"from":    []string{mail.FromEmail},
-		"sender":  []string{mail.Sender},
-		"subject": []string{mail.Subject},
-		"to":      mail.Recipients,
+		"from":       []string{mail.FromEmail},
+		"sender":     []string{mail.Sender},
+		"subject":    []string{mail.Subject},
+		"category":   []string{mail.Category},
+		"locale":     []string{mail.Locale},
+		"messageID":  []string{mail.MessageID},
+		"inReplyTo":  []string{mail.InReplyTo},
+		"references": mail.References,
+		"to":         mail.Recipients,
+	}
+
+	if mail.Version != 0 {
+		query.Set("version", strconv.FormatUint(uint64(mail.Version), 10))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(mail.Headers)) {
+		query.Add("header", key+": "+mail.Headers[key])
+	}
+
+	if len(mail.DSN.Notify) != 0 {
+		query.Set("notify", strings.Join(mail.DSN.Notify, ","))
+	}
+
+	if len(mail.DSN.Return) != 0 {
+		query.Set("ret", mail.DSN.Return)
+	}
+
+	if len(mail.DSN.EnvelopeID) != 0 {
+		query.Set("envid", mail.DSN.EnvelopeID)
 	}
 
 	queryPath := fmt.Sprintf("/render/%s?%s", url.PathEscape(mail.Tpl), query.Encode())
 
-	_, err := s.req.Path(queryPath).JSON(ctx, mail.Payload)
-	return err
+	resp, err := s.req.Path(queryPath).JSON(ctx, mail.Payload)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := httpjson.Read[model.Result](resp)
+	if err != nil {
+		return "", fmt.Errorf("read result: %w", err)
+	}
+
+	return result.MessageID, nil
 }
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
 
@@ -50,6 +52,8 @@ func TestSend(t *testing.T) {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
+
+		_, _ = w.Write([]byte(`{"message_id":"<id@localhost>"}`))
 	}))
 	defer testServer.Close()
 
@@ -76,7 +80,7 @@ func TestSend(t *testing.T) {
 			args{
 				mailRequest: model.NewMailRequest(),
 			},
-			errors.New("from email is required"),
+			errors.New("recipients are required"),
 		},
 		"invalid http": {
 			Service{
@@ -87,6 +91,15 @@ func TestSend(t *testing.T) {
 			},
 			errors.New("HTTP/401"),
 		},
+		"sender from metadata": {
+			Service{
+				req: request.Post(testServer.URL).BasicAuth("admin", "password"),
+			},
+			args{
+				mailRequest: model.NewMailRequest().To("bob@localhost").Template("test"),
+			},
+			nil,
+		},
 		"http": {
 			Service{
 				req: request.Post(testServer.URL).BasicAuth("admin", "password"),
@@ -100,7 +113,7 @@ func TestSend(t *testing.T) {
 
 	for intention, testCase := range cases {
 		t.Run(intention, func(t *testing.T) {
-			gotErr := testCase.instance.Send(context.TODO(), testCase.args.mailRequest)
+			_, gotErr := testCase.instance.Send(context.TODO(), testCase.args.mailRequest)
 
 			failed := false
 
@@ -118,3 +131,49 @@ func TestSend(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPSendQuery(t *testing.T) {
+	t.Parallel()
+
+	queries := make(chan url.Values, 1)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+
+		_, _ = w.Write([]byte(`{"message_id":"<id@vibioh.fr>"}`))
+	}))
+	t.Cleanup(testServer.Close)
+
+	instance := Service{req: request.Post(testServer.URL)}
+
+	mailRequest := model.NewMailRequest().From("alice@localhost").To("bob@localhost").Template("test").WithDSN(model.DSN{
+		Notify:     []string{"SUCCESS", "FAILURE"},
+		Return:     "HDRS",
+		EnvelopeID: "id-1",
+	}).WithHeader("X-Priority", "1").WithHeader("Auto-Submitted", "auto-generated")
+
+	messageID, err := instance.Send(context.TODO(), mailRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if messageID != "<id@vibioh.fr>" {
+		t.Errorf("Send() = `%s`, want the one generated by the mailer", messageID)
+	}
+
+	got := <-queries
+
+	want := url.Values{
+		"notify":    []string{"SUCCESS,FAILURE"},
+		"ret":       []string{"HDRS"},
+		"envid":     []string{"id-1"},
+		"header":    []string{"Auto-Submitted: auto-generated", "X-Priority: 1"},
+		"messageID": []string{""},
+	}
+
+	for key, values := range want {
+		if !slices.Equal(got[key], values) {
+			t.Errorf("httpSend() query `%s` = %v, want %v", key, got[key], values)
+		}
+	}
+}
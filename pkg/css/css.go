@@ -0,0 +1,171 @@
+package css
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// embedAttribute marks a style block to keep as is, e.g. for rules targeting a specific client
+const embedAttribute = "data-embed"
+
+// inlineSpecificity ranks declarations of the style attribute above every stylesheet rule, except important ones
+var inlineSpecificity = [3]int{1 << 16}
+
+type matched struct {
+	declaration
+	specificity [3]int
+	order       int
+}
+
+// Inline moves the rules of style blocks in the style attribute of matching elements of the body, for clients ignoring style blocks. Media queries, and rules whose selector can't be matched statically (e.g. `a:hover`), are kept in a style block.
+func Inline(content []byte) ([]byte, error) {
+	document, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	var styles []*html.Node
+	var body *html.Node
+
+	for node := range document.Descendants() {
+		if node.Type != html.ElementNode {
+			continue
+		}
+
+		switch node.DataAtom {
+		case atom.Style:
+			if _, ok := attributeIndex(node, embedAttribute); !ok {
+				styles = append(styles, node)
+			}
+
+		case atom.Body:
+			body = node
+		}
+	}
+
+	var sheet stylesheet
+
+	for _, style := range styles {
+		extract(&sheet, style)
+	}
+
+	if len(sheet.rules) != 0 && body != nil {
+		for node := range body.Descendants() {
+			if node.Type == html.ElementNode && node.DataAtom != atom.Style && node.DataAtom != atom.Script {
+				apply(sheet.rules, node)
+			}
+		}
+	}
+
+	var output bytes.Buffer
+	if err = html.Render(&output, document); err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+// extract parses the style block, keeping only what can't be inlined, or removing it if everything can
+func extract(sheet *stylesheet, node *html.Node) {
+	var source strings.Builder
+
+	for child := range node.ChildNodes() {
+		if child.Type == html.TextNode {
+			source.WriteString(child.Data)
+		}
+	}
+
+	kept := sheet.parse(source.String())
+
+	for child := node.FirstChild; child != nil; child = node.FirstChild {
+		node.RemoveChild(child)
+	}
+
+	if len(kept) == 0 {
+		node.Parent.RemoveChild(node)
+		return
+	}
+
+	node.AppendChild(&html.Node{Type: html.TextNode, Data: "\n" + kept + "\n"})
+}
+
+// apply writes the declarations of matching rules in the style attribute, by precedence
+func apply(rules []rule, node *html.Node) {
+	var declarations []matched
+
+	for _, rule := range rules {
+		if !rule.selector.matches(node) {
+			continue
+		}
+
+		for _, item := range rule.declarations {
+			declarations = append(declarations, matched{declaration: item, specificity: rule.specificity, order: rule.order})
+		}
+	}
+
+	if len(declarations) == 0 {
+		return
+	}
+
+	index, hasStyle := attributeIndex(node, "style")
+	if hasStyle {
+		for _, item := range parseDeclarations(node.Attr[index].Val) {
+			declarations = append(declarations, matched{declaration: item, specificity: inlineSpecificity, order: len(rules)})
+		}
+	}
+
+	slices.SortStableFunc(declarations, func(a, b matched) int {
+		if a.important != b.important {
+			if a.important {
+				return 1
+			}
+
+			return -1
+		}
+
+		if result := slices.Compare(a.specificity[:], b.specificity[:]); result != 0 {
+			return result
+		}
+
+		return cmp.Compare(a.order, b.order)
+	})
+
+	values := make(map[string]string)
+	var properties []string
+
+	for _, item := range declarations {
+		if _, ok := values[item.property]; !ok {
+			properties = append(properties, item.property)
+		}
+
+		values[item.property] = item.value
+	}
+
+	var style strings.Builder
+
+	for _, property := range properties {
+		style.WriteString(property + ":" + values[property] + ";")
+	}
+
+	if hasStyle {
+		node.Attr[index].Val = style.String()
+	} else {
+		node.Attr = append(node.Attr, html.Attribute{Key: "style", Val: style.String()})
+	}
+}
+
+func attributeIndex(node *html.Node, key string) (int, bool) {
+	for index, attr := range node.Attr {
+		if attr.Key == key {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
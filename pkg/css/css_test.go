@@ -0,0 +1,58 @@
+package css
+
+import (
+	"testing"
+)
+
+func TestInline(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		input string
+		want  string
+	}{
+		"no style": {
+			`<!doctype html><html><head></head><body><p>Hello</p></body></html>`,
+			`<!DOCTYPE html><html><head></head><body><p>Hello</p></body></html>`,
+		},
+		"simple": {
+			`<html><head><style>p { color: red; } .title { font-size: 20px }</style></head><body><p class="title">Hello</p></body></html>`,
+			`<html><head></head><body><p class="title" style="color:red;font-size:20px;">Hello</p></body></html>`,
+		},
+		"specificity": {
+			`<html><head><style>#main { color: blue } p.intro { color: green } p { color: red; margin: 0 }</style></head><body><p id="main" class="intro">Hello</p><p class="intro">World</p></body></html>`,
+			`<html><head></head><body><p id="main" class="intro" style="color:blue;margin:0;">Hello</p><p class="intro" style="color:green;margin:0;">World</p></body></html>`,
+		},
+		"existing style": {
+			`<html><head><style>p { color: red; padding: 0 !important }</style></head><body><p style="color: blue; padding: 10px">Hello</p></body></html>`,
+			`<html><head></head><body><p style="color:blue;padding:0;">Hello</p></body></html>`,
+		},
+		"combinators": {
+			`<html><head><style>table td { color: red } div > span { color: blue }</style></head><body><table><tr><td>A</td></tr></table><div><p><span>B</span></p><span>C</span></div></body></html>`,
+			`<html><head></head><body><table><tbody><tr><td style="color:red;">A</td></tr></tbody></table><div><p><span>B</span></p><span style="color:blue;">C</span></div></body></html>`,
+		},
+		"kept in head": {
+			`<html><head><style>/* comment */ a { color: red } a:hover { color: blue } @media (max-width: 480px) { a { color: green } }</style></head><body><a href="#">Link</a></body></html>`,
+			"<html><head><style>\na:hover { color: blue }\n@media (max-width: 480px) { a { color: green } }\n</style></head><body><a href=\"#\" style=\"color:red;\">Link</a></body></html>",
+		},
+		"embedded": {
+			`<html><head><style data-embed>p { color: red }</style></head><body><p>Hello</p></body></html>`,
+			`<html><head><style data-embed="">p { color: red }</style></head><body><p>Hello</p></body></html>`,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Inline([]byte(testCase.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := string(actual); got != testCase.want {
+				t.Errorf("Inline() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package css
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// compound is a simple selector sequence, e.g. `td.header#main`, with the combinator linking it to the previous one
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	child   bool
+}
+
+// selector is a sequence of compounds, from the outermost to the matched element
+type selector []compound
+
+// parseSelector supports type, class, id and universal selectors, with descendant and child combinators
+func parseSelector(raw string) (selector, bool) {
+	if strings.ContainsAny(raw, ":[+~") {
+		return nil, false
+	}
+
+	var output selector
+	var child bool
+
+	for part := range strings.FieldsSeq(strings.ReplaceAll(raw, ">", " > ")) {
+		if part == ">" {
+			if len(output) == 0 || child {
+				return nil, false
+			}
+
+			child = true
+
+			continue
+		}
+
+		parsed, ok := parseCompound(part)
+		if !ok {
+			return nil, false
+		}
+
+		parsed.child = child
+		child = false
+
+		output = append(output, parsed)
+	}
+
+	if len(output) == 0 || child {
+		return nil, false
+	}
+
+	return output, true
+}
+
+func parseCompound(raw string) (compound, bool) {
+	var output compound
+
+	end := strings.IndexAny(raw, ".#")
+	if end == -1 {
+		end = len(raw)
+	}
+
+	if tag := raw[:end]; tag != "*" {
+		output.tag = strings.ToLower(tag)
+	}
+
+	for rest := raw[end:]; len(rest) != 0; {
+		kind := rest[0]
+		rest = rest[1:]
+
+		next := strings.IndexAny(rest, ".#")
+		if next == -1 {
+			next = len(rest)
+		}
+
+		name := rest[:next]
+		rest = rest[next:]
+
+		if len(name) == 0 {
+			return output, false
+		}
+
+		if kind == '#' {
+			output.id = name
+		} else {
+			output.classes = append(output.classes, name)
+		}
+	}
+
+	return output, true
+}
+
+func (s selector) specificity() [3]int {
+	var output [3]int
+
+	for _, part := range s {
+		if len(part.id) != 0 {
+			output[0]++
+		}
+
+		output[1] += len(part.classes)
+
+		if len(part.tag) != 0 {
+			output[2]++
+		}
+	}
+
+	return output
+}
+
+func (s selector) matches(node *html.Node) bool {
+	return s.matchesFrom(node, len(s)-1)
+}
+
+func (s selector) matchesFrom(node *html.Node, index int) bool {
+	if !s[index].matches(node) {
+		return false
+	}
+
+	if index == 0 {
+		return true
+	}
+
+	for parent := node.Parent; parent != nil && parent.Type == html.ElementNode; parent = parent.Parent {
+		if s.matchesFrom(parent, index-1) {
+			return true
+		}
+
+		if s[index].child {
+			return false
+		}
+	}
+
+	return false
+}
+
+func (c compound) matches(node *html.Node) bool {
+	if len(c.tag) != 0 && node.Data != c.tag {
+		return false
+	}
+
+	if len(c.id) != 0 && attribute(node, "id") != c.id {
+		return false
+	}
+
+	if len(c.classes) == 0 {
+		return true
+	}
+
+	classes := strings.Fields(attribute(node, "class"))
+
+	for _, class := range c.classes {
+		if !slices.Contains(classes, class) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func attribute(node *html.Node, key string) string {
+	if index, ok := attributeIndex(node, key); ok {
+		return node.Attr[index].Val
+	}
+
+	return ""
+}
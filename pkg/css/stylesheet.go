@@ -0,0 +1,172 @@
+package css
+
+import (
+	"strings"
+)
+
+type declaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+// rule is a stylesheet rule with a single selector that can be matched against elements
+type rule struct {
+	selector     selector
+	declarations []declaration
+	specificity  [3]int
+	order        int
+}
+
+// stylesheet gathers the rules to inline of every style block
+type stylesheet struct {
+	rules []rule
+}
+
+// parse adds rules of the source to the stylesheet, and returns what has to be kept in a style block: at-rules (e.g. media queries) and unsupported selectors (e.g. pseudo-classes)
+func (s *stylesheet) parse(source string) string {
+	var kept strings.Builder
+
+	source = stripComments(source)
+
+	for {
+		source = strings.TrimSpace(source)
+		if len(source) == 0 {
+			return kept.String()
+		}
+
+		if source[0] == '@' {
+			end := atRuleEnd(source)
+			keep(&kept, source[:end])
+			source = source[end:]
+
+			continue
+		}
+
+		open := strings.IndexByte(source, '{')
+		if open == -1 {
+			return kept.String()
+		}
+
+		closing := strings.IndexByte(source[open:], '}')
+		if closing == -1 {
+			return kept.String()
+		}
+
+		selectors := source[:open]
+		body := source[open+1 : open+closing]
+		source = source[open+closing+1:]
+
+		declarations := parseDeclarations(body)
+		if len(declarations) == 0 {
+			continue
+		}
+
+		for raw := range strings.SplitSeq(selectors, ",") {
+			raw = strings.TrimSpace(raw)
+			if len(raw) == 0 {
+				continue
+			}
+
+			parsed, ok := parseSelector(raw)
+			if !ok {
+				keep(&kept, raw+" {"+body+"}")
+				continue
+			}
+
+			s.rules = append(s.rules, rule{
+				selector:     parsed,
+				declarations: declarations,
+				specificity:  parsed.specificity(),
+				order:        len(s.rules),
+			})
+		}
+	}
+}
+
+func keep(kept *strings.Builder, source string) {
+	if kept.Len() != 0 {
+		kept.WriteString("\n")
+	}
+
+	kept.WriteString(strings.TrimSpace(source))
+}
+
+// atRuleEnd gives the end of the at-rule, either a statement ending with `;` or a block with nested ones
+func atRuleEnd(source string) int {
+	var depth int
+
+	for index, char := range source {
+		switch char {
+		case ';':
+			if depth == 0 {
+				return index + 1
+			}
+
+		case '{':
+			depth++
+
+		case '}':
+			depth--
+
+			if depth <= 0 {
+				return index + 1
+			}
+		}
+	}
+
+	return len(source)
+}
+
+func stripComments(source string) string {
+	var output strings.Builder
+
+	for {
+		start := strings.Index(source, "/*")
+		if start == -1 {
+			output.WriteString(source)
+			return output.String()
+		}
+
+		output.WriteString(source[:start])
+
+		end := strings.Index(source[start+2:], "*/")
+		if end == -1 {
+			return output.String()
+		}
+
+		source = source[start+2+end+2:]
+	}
+}
+
+func parseDeclarations(body string) []declaration {
+	var output []declaration
+
+	for raw := range strings.SplitSeq(body, ";") {
+		property, value, ok := strings.Cut(raw, ":")
+		if !ok {
+			continue
+		}
+
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+
+		if len(property) == 0 || len(value) == 0 {
+			continue
+		}
+
+		var important bool
+		if index := strings.LastIndex(strings.ToLower(value), "!important"); index != -1 {
+			important = true
+			value = strings.TrimSpace(value[:index])
+		}
+
+		output = append(output, declaration{
+			property:  property,
+			value:     value,
+			important: important,
+		})
+	}
+
+	return output
+}
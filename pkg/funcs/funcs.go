@@ -0,0 +1,303 @@
+// Package funcs provides the functions available in templates, for formatting values of an email
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ViBiOh/mailer/pkg/i18n"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+const ellipsis = "…"
+
+// New returns every function of the library, by name
+func New() template.FuncMap {
+	return template.FuncMap{
+		"odd": func(i int) bool {
+			return i%2 == 0
+		},
+		"split": func(s, separator string) []string {
+			return strings.Split(s, separator)
+		},
+		"contains": func(s, substr string) bool {
+			return strings.Contains(s, substr)
+		},
+		"date":     Date,
+		"relative": Relative,
+		"number":   Number,
+		"currency": Currency,
+		"plural":   Plural,
+		"truncate": Truncate,
+		"default":  Default,
+		"url":      URL,
+		"markdown": Markdown,
+		"dict":     Dict,
+		"list":     List,
+		"keys":     Keys,
+	}
+}
+
+// Date formats the value with the Go layout, in the optional timezone, e.g. `{{ date "02/01/2006 15:04" .CreatedAt "Europe/Paris" }}`
+func Date(layout string, value any, timezone ...string) (string, error) {
+	instant, err := toTime(value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(timezone) != 0 && len(timezone[0]) != 0 {
+		location, err := time.LoadLocation(timezone[0])
+		if err != nil {
+			return "", fmt.Errorf("load timezone: %w", err)
+		}
+
+		instant = instant.In(location)
+	}
+
+	return instant.Format(layout), nil
+}
+
+// Relative describes the value relatively to now, in a human-readable way, e.g. `3 days ago` or `in 2 hours`
+func Relative(value any) (string, error) {
+	instant, err := toTime(value)
+	if err != nil {
+		return "", err
+	}
+
+	return relative(time.Since(instant)), nil
+}
+
+func relative(elapsed time.Duration) string {
+	future := elapsed < 0
+	if future {
+		elapsed = -elapsed
+	}
+
+	var amount int
+	var unit string
+
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		amount, unit = int(elapsed/time.Minute), "minute"
+	case elapsed < 24*time.Hour:
+		amount, unit = int(elapsed/time.Hour), "hour"
+	case elapsed < 30*24*time.Hour:
+		amount, unit = int(elapsed/(24*time.Hour)), "day"
+	case elapsed < 365*24*time.Hour:
+		amount, unit = int(elapsed/(30*24*time.Hour)), "month"
+	default:
+		amount, unit = int(elapsed/(365*24*time.Hour)), "year"
+	}
+
+	if amount > 1 {
+		unit += "s"
+	}
+
+	if future {
+		return fmt.Sprintf("in %d %s", amount, unit)
+	}
+
+	return fmt.Sprintf("%d %s ago", amount, unit)
+}
+
+// Number formats the value with the separators of the locale, e.g. `{{ number 1234.5 "fr" }}` gives `1 234,5`
+func Number(value any, locale string) (string, error) {
+	amount, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+
+	return printer(locale).Sprint(number.Decimal(amount)), nil
+}
+
+// Currency formats the amount in the ISO 4217 currency with the separators of the locale, e.g. `{{ currency 1234.5 "EUR" "fr" }}` gives `€ 1 234,50`
+func Currency(value any, code, locale string) (string, error) {
+	amount, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("parse currency: %w", err)
+	}
+
+	return printer(locale).Sprint(currency.Symbol(unit.Amount(amount))), nil
+}
+
+func printer(locale string) *message.Printer {
+	tag, err := language.Parse(i18n.Normalize(locale))
+	if err != nil {
+		tag = language.English
+	}
+
+	return message.NewPrinter(tag)
+}
+
+// Plural chooses between the singular and the plural word depending on the count, following the rules of the optional locale (English by default), e.g. `{{ plural (len .releases) "release" "releases" }}`
+func Plural(count any, singular, plural string, locale ...string) (string, error) {
+	amount, err := toFloat(count)
+	if err != nil {
+		return "", err
+	}
+
+	rules := "en"
+	if len(locale) != 0 && len(locale[0]) != 0 {
+		rules = locale[0]
+	}
+
+	if i18n.Plural(rules, int(amount)) == "one" && amount == math.Trunc(amount) {
+		return singular, nil
+	}
+
+	return plural, nil
+}
+
+// Truncate shortens the text to the given number of characters, ending with an ellipsis, e.g. `{{ .Description | truncate 80 }}`
+func Truncate(length int, text string) string {
+	if length <= 0 {
+		return ""
+	}
+
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)
+
+	return strings.TrimSpace(string(runes[:length-1])) + ellipsis
+}
+
+// Default returns the fallback when the value is empty, e.g. `{{ .Name | default "friend" }}`
+func Default(fallback, value any) any {
+	if isEmpty(value) {
+		return fallback
+	}
+
+	return value
+}
+
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return reflected.Len() == 0
+	default:
+		return reflected.IsZero()
+	}
+}
+
+// URL adds query parameters to the base URL, properly escaped, e.g. `{{ url "https://vibioh.fr" "utm_source" "mailer" }}`
+func URL(base string, pairs ...any) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", errors.New("query parameters must be key and value pairs")
+	}
+
+	output, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+
+	query := output.Query()
+	for index := 0; index < len(pairs); index += 2 {
+		query.Add(fmt.Sprint(pairs[index]), fmt.Sprint(pairs[index+1]))
+	}
+
+	output.RawQuery = query.Encode()
+
+	return output.String(), nil
+}
+
+// Dict creates a map from key and value pairs, e.g. for passing several values to a sub-template `{{ template "button" (dict "URL" .URL "Label" "Open") }}`
+func Dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict must be key and value pairs")
+	}
+
+	output := make(map[string]any, len(pairs)/2)
+	for index := 0; index < len(pairs); index += 2 {
+		key, ok := pairs[index].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key at index %d is not a string", index)
+		}
+
+		output[key] = pairs[index+1]
+	}
+
+	return output, nil
+}
+
+// List creates a list from its arguments, e.g. `{{ range list "github" "docker" }}`
+func List(items ...any) []any {
+	return items
+}
+
+// Keys returns the sorted keys of a map, for iterating in a stable order
+func Keys(value map[string]any) []string {
+	return slices.Sorted(maps.Keys(value))
+}
+
+func toTime(value any) (time.Time, error) {
+	switch instant := value.(type) {
+	case time.Time:
+		return instant, nil
+	case *time.Time:
+		if instant == nil {
+			return time.Time{}, errors.New("time is nil")
+		}
+
+		return *instant, nil
+	case string:
+		if output, err := time.Parse(time.RFC3339, instant); err == nil {
+			return output, nil
+		}
+
+		output, err := time.Parse(time.DateOnly, instant)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse time `%s`: must be RFC3339 or a date", instant)
+		}
+
+		return output, nil
+	default:
+		seconds, err := toFloat(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("time must be a time, a string or a unix timestamp: %w", err)
+		}
+
+		return time.Unix(int64(seconds), 0), nil
+	}
+}
+
+func toFloat(value any) (float64, error) {
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflected.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float(), nil
+	default:
+		return 0, fmt.Errorf("`%v` is not a number", value)
+	}
+}
@@ -0,0 +1,430 @@
+package funcs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDate(t *testing.T) {
+	t.Parallel()
+
+	instant := time.Date(2024, time.March, 10, 14, 30, 0, 0, time.UTC)
+
+	type args struct {
+		value    any
+		layout   string
+		timezone []string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr bool
+	}{
+		"time": {
+			args{layout: "02/01/2006 15:04", value: instant},
+			"10/03/2024 14:30",
+			false,
+		},
+		"timezone": {
+			args{layout: "02/01/2006 15:04", value: instant, timezone: []string{"Europe/Paris"}},
+			"10/03/2024 15:30",
+			false,
+		},
+		"string": {
+			args{layout: time.DateOnly, value: "2024-03-10T23:30:00-05:00", timezone: []string{"UTC"}},
+			"2024-03-11",
+			false,
+		},
+		"unix": {
+			args{layout: time.RFC3339, value: float64(instant.Unix()), timezone: []string{"UTC"}},
+			"2024-03-10T14:30:00Z",
+			false,
+		},
+		"invalid timezone": {
+			args{layout: time.RFC3339, value: instant, timezone: []string{"Mars/Olympus"}},
+			"",
+			true,
+		},
+		"invalid value": {
+			args{layout: time.RFC3339, value: "yesterday"},
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Date(testCase.args.layout, testCase.args.value, testCase.args.timezone...)
+
+			if (gotErr != nil) != testCase.wantErr || got != testCase.want {
+				t.Errorf("Date() = (`%s`, `%v`), want `%s`", got, gotErr, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRelative(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		elapsed time.Duration
+		want    string
+	}{
+		"now": {
+			10 * time.Second,
+			"just now",
+		},
+		"minutes": {
+			5 * time.Minute,
+			"5 minutes ago",
+		},
+		"hour": {
+			time.Hour,
+			"1 hour ago",
+		},
+		"days": {
+			-3 * 24 * time.Hour,
+			"in 3 days",
+		},
+		"years": {
+			800 * 24 * time.Hour,
+			"2 years ago",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := relative(testCase.elapsed); got != testCase.want {
+				t.Errorf("relative() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestNumber(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		value   any
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		"english": {
+			1234567.5,
+			"en",
+			"1,234,567.5",
+			false,
+		},
+		"german": {
+			1234567,
+			"de",
+			"1.234.567",
+			false,
+		},
+		"unknown locale": {
+			uint(1234),
+			"",
+			"1,234",
+			false,
+		},
+		"not a number": {
+			"1234",
+			"en",
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Number(testCase.value, testCase.locale)
+
+			if (gotErr != nil) != testCase.wantErr || got != testCase.want {
+				t.Errorf("Number() = (`%s`, `%v`), want `%s`", got, gotErr, testCase.want)
+			}
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		value  any
+		code   string
+		locale string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr bool
+	}{
+		"dollar": {
+			args{value: 1234.5, code: "USD", locale: "en"},
+			"$ 1,234.50",
+			false,
+		},
+		"euro": {
+			args{value: 1234.5, code: "EUR", locale: "de"},
+			"€ 1.234,50",
+			false,
+		},
+		"invalid currency": {
+			args{value: 1234.5, code: "ABCD", locale: "en"},
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Currency(testCase.args.value, testCase.args.code, testCase.args.locale)
+
+			if (gotErr != nil) != testCase.wantErr || got != testCase.want {
+				t.Errorf("Currency() = (`%s`, `%v`), want `%s`", got, gotErr, testCase.want)
+			}
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		count  any
+		locale []string
+		want   string
+	}{
+		"one": {
+			1,
+			nil,
+			"release",
+		},
+		"zero": {
+			0,
+			nil,
+			"releases",
+		},
+		"many": {
+			float64(3),
+			nil,
+			"releases",
+		},
+		"french zero": {
+			0,
+			[]string{"fr"},
+			"release",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Plural(testCase.count, "release", "releases", testCase.locale...)
+
+			if gotErr != nil || got != testCase.want {
+				t.Errorf("Plural() = (`%s`, `%v`), want `%s`", got, gotErr, testCase.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		text   string
+		length int
+		want   string
+	}{
+		"short": {
+			"Hello",
+			10,
+			"Hello",
+		},
+		"long": {
+			"Hello World",
+			7,
+			"Hello…",
+		},
+		"runes": {
+			"Héllo Wörld",
+			9,
+			"Héllo Wö…",
+		},
+		"zero": {
+			"Hello",
+			0,
+			"",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Truncate(testCase.length, testCase.text); got != testCase.want {
+				t.Errorf("Truncate() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		value any
+		want  any
+	}{
+		"nil": {
+			nil,
+			"friend",
+		},
+		"empty string": {
+			"",
+			"friend",
+		},
+		"empty list": {
+			[]any{},
+			"friend",
+		},
+		"zero": {
+			0,
+			"friend",
+		},
+		"value": {
+			"Bob",
+			"Bob",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Default("friend", testCase.value); got != testCase.want {
+				t.Errorf("Default() = `%v`, want `%v`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		base    string
+		pairs   []any
+		want    string
+		wantErr bool
+	}{
+		"simple": {
+			"https://vibioh.fr/releases",
+			[]any{"utm_source", "mailer", "page", 2},
+			"https://vibioh.fr/releases?page=2&utm_source=mailer",
+			false,
+		},
+		"escaped": {
+			"https://vibioh.fr/?sort=asc",
+			[]any{"q", "a&b=c d"},
+			"https://vibioh.fr/?q=a%26b%3Dc+d&sort=asc",
+			false,
+		},
+		"odd": {
+			"https://vibioh.fr",
+			[]any{"q"},
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := URL(testCase.base, testCase.pairs...)
+
+			if (gotErr != nil) != testCase.wantErr || got != testCase.want {
+				t.Errorf("URL() = (`%s`, `%v`), want `%s`", got, gotErr, testCase.want)
+			}
+		})
+	}
+}
+
+func TestDict(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		pairs   []any
+		want    map[string]any
+		wantErr bool
+	}{
+		"valid": {
+			[]any{"URL", "https://vibioh.fr", "Count", 2},
+			map[string]any{"URL": "https://vibioh.fr", "Count": 2},
+			false,
+		},
+		"odd": {
+			[]any{"URL"},
+			nil,
+			true,
+		},
+		"invalid key": {
+			[]any{1, "value"},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Dict(testCase.pairs...)
+
+			if (gotErr != nil) != testCase.wantErr || len(got) != len(testCase.want) {
+				t.Errorf("Dict() = (%v, `%v`), want %v", got, gotErr, testCase.want)
+				return
+			}
+
+			for key, value := range testCase.want {
+				if got[key] != value {
+					t.Errorf("Dict()[%s] = %v, want %v", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	got := List("github", 2)
+
+	if len(got) != 2 || got[0] != "github" || got[1] != 2 {
+		t.Errorf("List() = %v, want [github 2]", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	got := Keys(map[string]any{"docker": 1, "github": 2, "helm": 3})
+
+	if len(got) != 3 || got[0] != "docker" || got[1] != "github" || got[2] != "helm" {
+		t.Errorf("Keys() = %v, want [docker github helm]", got)
+	}
+}
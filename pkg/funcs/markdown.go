@@ -0,0 +1,149 @@
+package funcs
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	unorderedPattern = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	orderedPattern   = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	linkPattern      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldPattern      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicPattern    = regexp.MustCompile(`\*(.+?)\*`)
+)
+
+type markdownBlock struct {
+	tag   string
+	lines []string
+}
+
+// Markdown converts a markdown text to HTML accepted in a `mj-text`: headings, paragraphs, lists, code blocks, links, bold, italic and inline code, e.g. `<mj-text>{{ markdown .Body }}</mj-text>`
+func Markdown(content string) string {
+	var output strings.Builder
+
+	for _, block := range markdownBlocks(content) {
+		switch block.tag {
+		case "pre":
+			fmt.Fprintf(&output, "<pre><code>%s</code></pre>", html.EscapeString(strings.Join(block.lines, "\n")))
+		case "ul", "ol":
+			fmt.Fprintf(&output, "<%s>", block.tag)
+			for _, line := range block.lines {
+				fmt.Fprintf(&output, "<li>%s</li>", inline(line))
+			}
+			fmt.Fprintf(&output, "</%s>", block.tag)
+		default:
+			fmt.Fprintf(&output, "<%s>%s</%s>", block.tag, inline(strings.Join(block.lines, "\n")), block.tag)
+		}
+	}
+
+	return output.String()
+}
+
+func markdownBlocks(content string) []markdownBlock {
+	var blocks []markdownBlock
+	var current *markdownBlock
+
+	flush := func() {
+		if current != nil {
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+
+	appendTo := func(tag, line string) {
+		if current == nil || current.tag != tag {
+			flush()
+			current = &markdownBlock{tag: tag}
+		}
+
+		current.lines = append(current.lines, line)
+	}
+
+	for line := range strings.SplitSeq(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if current != nil && current.tag == "pre" {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				flush()
+			} else {
+				current.lines = append(current.lines, line)
+			}
+
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			current = &markdownBlock{tag: "pre"}
+			continue
+		}
+
+		if len(trimmed) == 0 {
+			flush()
+			continue
+		}
+
+		if matches := headingPattern.FindStringSubmatch(trimmed); matches != nil {
+			flush()
+			blocks = append(blocks, markdownBlock{tag: fmt.Sprintf("h%d", len(matches[1])), lines: []string{matches[2]}})
+			continue
+		}
+
+		if matches := unorderedPattern.FindStringSubmatch(trimmed); matches != nil {
+			appendTo("ul", matches[1])
+			continue
+		}
+
+		if matches := orderedPattern.FindStringSubmatch(trimmed); matches != nil {
+			appendTo("ol", matches[1])
+			continue
+		}
+
+		if current != nil && current.tag != "p" {
+			flush()
+		}
+
+		appendTo("p", trimmed)
+	}
+
+	flush()
+
+	return blocks
+}
+
+// inline converts spans of a line, code spans being kept verbatim
+func inline(content string) string {
+	var output strings.Builder
+
+	for index, part := range strings.Split(content, "`") {
+		if index%2 == 1 {
+			fmt.Fprintf(&output, "<code>%s</code>", html.EscapeString(part))
+			continue
+		}
+
+		part = html.EscapeString(part)
+		part = linkPattern.ReplaceAllStringFunc(part, link)
+		part = boldPattern.ReplaceAllString(part, "<strong>$1</strong>")
+		part = italicPattern.ReplaceAllString(part, "<em>$1</em>")
+
+		output.WriteString(part)
+	}
+
+	return output.String()
+}
+
+func link(match string) string {
+	parts := linkPattern.FindStringSubmatch(match)
+
+	target, err := url.Parse(html.UnescapeString(parts[2]))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https" && target.Scheme != "mailto") {
+		return parts[1]
+	}
+
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(target.String()), parts[1])
+}
@@ -0,0 +1,51 @@
+package funcs
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		content string
+		want    string
+	}{
+		"paragraphs": {
+			"Hello\nWorld\n\nBye",
+			"<p>Hello\nWorld</p><p>Bye</p>",
+		},
+		"heading": {
+			"## Releases\nNew versions",
+			"<h2>Releases</h2><p>New versions</p>",
+		},
+		"lists": {
+			"- github\n- docker\n\n1. first\n2. second",
+			"<ul><li>github</li><li>docker</li></ul><ol><li>first</li><li>second</li></ol>",
+		},
+		"inline": {
+			"**Hello** *World*, see `<main>` on [GitHub](https://github.com/ViBiOh?tab=repositories&q=mailer)",
+			`<p><strong>Hello</strong> <em>World</em>, see <code>&lt;main&gt;</code> on <a href="https://github.com/ViBiOh?tab=repositories&amp;q=mailer">GitHub</a></p>`,
+		},
+		"unsafe link": {
+			"[click](javascript:void)",
+			"<p>click</p>",
+		},
+		"escaped": {
+			"<script>alert('hello')</script>",
+			"<p>&lt;script&gt;alert(&#39;hello&#39;)&lt;/script&gt;</p>",
+		},
+		"code block": {
+			"```\nif a < b {\n\n}\n```",
+			"<pre><code>if a &lt; b {\n\n}</code></pre>",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Markdown(testCase.content); got != testCase.want {
+				t.Errorf("Markdown() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const maxBounceSize = 10 << 20
+
+func (s Service) HandleBounceList(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.bounceService.Authorize(bearer(r))) {
+		return
+	}
+
+	httpjson.WriteArray(r.Context(), w, http.StatusOK, s.bounceService.List())
+}
+
+func (s Service) HandleBounce(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.bounceService.Authorize(bearer(r))) {
+		return
+	}
+
+	statuses, err := s.bounceService.Handle(r.Context(), http.MaxBytesReader(w, r.Body, maxBounceSize))
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.WriteArray(r.Context(), w, http.StatusOK, statuses)
+}
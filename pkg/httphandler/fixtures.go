@@ -33,7 +33,12 @@ func (s Service) HandleFixture(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err := s.mailerService.CheckStrict(urlParts[0], content); err != nil {
+			w.Header().Add("X-Strict-Warning", err.Error())
+		}
+
 		httpjson.Write(r.Context(), w, http.StatusOK, content)
+		return
 	}
 
 	httperror.NotFound(r.Context(), w, nil)
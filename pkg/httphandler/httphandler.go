@@ -1,18 +1,33 @@
 package httphandler
 
 import (
+	"net/http"
+	"strings"
+
+	"github.com/ViBiOh/mailer/pkg/bounce"
 	"github.com/ViBiOh/mailer/pkg/mailer"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type Service struct {
-	tracer        trace.Tracer
-	mailerService mailer.Service
+	tracer             trace.Tracer
+	suppressionService suppression.Service
+	unsubscribeService unsubscribe.Service
+	bounceService      bounce.Service
+	storeService       store.Service
+	mailerService      mailer.Service
 }
 
-func New(mailerService mailer.Service, tracerProvider trace.TracerProvider) Service {
+func New(mailerService mailer.Service, suppressionService suppression.Service, unsubscribeService unsubscribe.Service, bounceService bounce.Service, storeService store.Service, tracerProvider trace.TracerProvider) Service {
 	service := Service{
-		mailerService: mailerService,
+		mailerService:      mailerService,
+		bounceService:      bounceService,
+		suppressionService: suppressionService,
+		unsubscribeService: unsubscribeService,
+		storeService:       storeService,
 	}
 
 	if tracerProvider != nil {
@@ -21,3 +36,13 @@ func New(mailerService mailer.Service, tracerProvider trace.TracerProvider) Serv
 
 	return service
 }
+
+// bearer extracts the token of the Authorization header
+func bearer(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -3,9 +3,13 @@ package httphandler
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -13,7 +17,17 @@ import (
 	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
 	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	"github.com/ViBiOh/mailer/pkg/mailer"
+	"github.com/ViBiOh/mailer/pkg/mjml"
 	"github.com/ViBiOh/mailer/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/schema"
+)
+
+const (
+	// mjmlWarningHeader lists, one per value, the validation errors of the MJML conversion on preview
+	mjmlWarningHeader = "X-Mailer-Mjml-Warning"
+	sizeHeader        = "X-Mailer-Size"
+	clippedHeader     = "X-Mailer-Clipped"
 )
 
 var bufferPool = sync.Pool{
@@ -42,7 +56,11 @@ func (s Service) HandlerTemplate(w http.ResponseWriter, r *http.Request) {
 	ctx, end := telemetry.StartSpan(r.Context(), s.tracer, "render")
 	defer end(&err)
 
-	mr := parseMailRequest(r)
+	mr, err := parseMailRequest(r)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
 
 	fixtureName := r.URL.Query().Get("fixture")
 	if fixtureName == "" {
@@ -55,12 +73,22 @@ func (s Service) HandlerTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Has("nocache") {
+		ctx = mjml.WithoutCache(ctx)
+	}
+
+	ctx, warnings := mjml.CollectWarnings(ctx)
+
 	mr = mr.Data(content)
 	output, err := s.mailerService.Render(ctx, mr)
-	if httperror.HandleError(ctx, w, err) {
+	if handleRenderError(ctx, w, err) {
 		return
 	}
 
+	for _, warning := range warnings() {
+		w.Header().Add(mjmlWarningHeader, warning.String())
+	}
+
 	writeOutput(r.Context(), w, output)
 }
 
@@ -70,7 +98,13 @@ func (s Service) HandlerSend(w http.ResponseWriter, r *http.Request) {
 	ctx, end := telemetry.StartSpan(r.Context(), s.tracer, "render")
 	defer end(&err)
 
-	mr := parseMailRequest(r)
+	mr, err := parseMailRequest(r)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
+
+	mr = s.mailerService.WithMetadata(ctx, mr)
 
 	content, err := httpjson.Parse[map[string]any](r)
 	if err != nil {
@@ -80,14 +114,53 @@ func (s Service) HandlerSend(w http.ResponseWriter, r *http.Request) {
 
 	mr = mr.Data(content)
 	output, err := s.mailerService.Render(ctx, mr)
-	if httperror.HandleError(r.Context(), w, err) {
+	if handleRenderError(ctx, w, err) {
 		return
 	}
 
 	s.sendOutput(ctx, w, mr, output)
 }
 
-func writeOutput(ctx context.Context, w http.ResponseWriter, output io.Reader) {
+type payloadError struct {
+	Error  string              `json:"error"`
+	Fields []schema.FieldError `json:"fields"`
+}
+
+// handleRenderError details every invalid field when payload doesn't match template's schema
+func handleRenderError(ctx context.Context, w http.ResponseWriter, err error) bool {
+	var validationErr schema.ValidationError
+	if errors.As(err, &validationErr) {
+		httpjson.Write(ctx, w, http.StatusBadRequest, payloadError{
+			Error:  err.Error(),
+			Fields: validationErr,
+		})
+
+		return true
+	}
+
+	return httperror.HandleError(ctx, w, err)
+}
+
+func writeOutput(ctx context.Context, w http.ResponseWriter, output mailer.Output) {
+	if len(output.Inlines) != 0 {
+		reader, err := withDataURIs(output)
+		if err != nil {
+			httperror.InternalServerError(ctx, w, err)
+			return
+		}
+
+		output.Reader = reader
+	}
+
+	if len(output.Variant) != 0 {
+		w.Header().Add(model.VariantHeader, output.Variant)
+	}
+
+	w.Header().Add(sizeHeader, strconv.Itoa(output.Size))
+	if output.Clipped {
+		w.Header().Add(clippedHeader, "true")
+	}
+
 	w.Header().Add("Content-Type", "text/html; charset=UTF-8")
 	w.Header().Add("Cache-Control", "no-cache")
 	w.Header().Add("X-UA-Compatible", "ie=edge")
@@ -101,26 +174,80 @@ func writeOutput(ctx context.Context, w http.ResponseWriter, output io.Reader) {
 	}
 }
 
-func (s Service) sendOutput(ctx context.Context, w http.ResponseWriter, mr model.MailRequest, output io.Reader) {
+// withDataURIs replaces `cid:` references of embedded images by data URIs, for displaying them in the browser
+func withDataURIs(output mailer.Output) (io.Reader, error) {
+	content, err := io.ReadAll(output)
+	if err != nil {
+		return nil, fmt.Errorf("read output: %w", err)
+	}
+
+	replacements := make([]string, 0, len(output.Inlines)*2)
+	for _, inline := range output.Inlines {
+		replacements = append(replacements, "cid:"+inline.ContentID, "data:"+inline.ContentType+";base64,"+base64.StdEncoding.EncodeToString(inline.Content))
+	}
+
+	return strings.NewReader(strings.NewReplacer(replacements...).Replace(string(content))), nil
+}
+
+func (s Service) sendOutput(ctx context.Context, w http.ResponseWriter, mr model.MailRequest, output mailer.Output) {
 	if err := mr.Check(); err != nil {
 		httperror.HandleError(ctx, w, httpModel.WrapInvalid(err))
 		return
 	}
 
-	if httperror.HandleError(ctx, w, s.mailerService.Send(ctx, mr.ConvertToMail(ctx, output))) {
+	result, err := s.mailerService.Send(ctx, output.Mail(ctx, mr))
+	if len(result.Sent) == 0 && httperror.HandleError(ctx, w, err) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if err != nil {
+		// Mail is partially sent, failed recipients are in the response so the caller doesn't send it twice to the others
+		slog.LogAttrs(ctx, slog.LevelError, "mail partially sent", slog.String("template", mr.Tpl), slog.Any("failed", result.Failed), slog.Any("error", err))
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, result)
 }
 
-func parseMailRequest(r *http.Request) model.MailRequest {
+func parseMailRequest(r *http.Request) (model.MailRequest, error) {
 	mr := model.NewMailRequest()
 
+	version, err := parseVersion(r)
+	if err != nil {
+		return mr, err
+	}
+
+	mr = mr.WithVersion(version)
+
 	mr = mr.Template(strings.Trim(r.PathValue("template"), "/"))
 	mr = mr.From(strings.TrimSpace(r.URL.Query().Get("from")))
 	mr = mr.As(strings.TrimSpace(r.URL.Query().Get("sender")))
 	mr = mr.WithSubject(strings.TrimSpace(r.URL.Query().Get("subject")))
+	mr = mr.WithCategory(strings.TrimSpace(r.URL.Query().Get("category")))
+	mr = mr.WithLocale(strings.TrimSpace(r.URL.Query().Get("locale")))
+
+	if messageID := r.URL.Query().Get("messageID"); len(messageID) != 0 {
+		mr = mr.WithMessageID(messageID)
+	}
+
+	if inReplyTo := r.URL.Query().Get("inReplyTo"); len(inReplyTo) != 0 {
+		mr = mr.ReplyTo(inReplyTo)
+	}
+
+	for _, reference := range r.URL.Query()["references"] {
+		if cleanReference := strings.TrimSpace(reference); len(cleanReference) != 0 {
+			mr = mr.WithReferences(cleanReference)
+		}
+	}
+
+	for _, rawHeader := range r.URL.Query()["header"] {
+		if key, value, ok := strings.Cut(rawHeader, ":"); ok {
+			mr = mr.WithHeader(strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+	}
+
+	if dsn := parseDSN(r); !dsn.IsZero() {
+		mr = mr.WithDSN(dsn)
+	}
 
 	for _, rawTo := range r.URL.Query()["to"] {
 		if cleanTo := strings.TrimSpace(rawTo); len(cleanTo) != 0 {
@@ -128,5 +255,22 @@ func parseMailRequest(r *http.Request) model.MailRequest {
 		}
 	}
 
-	return mr
+	return mr, nil
+}
+
+func parseDSN(r *http.Request) model.DSN {
+	var dsn model.DSN
+
+	for _, rawNotify := range r.URL.Query()["notify"] {
+		for notify := range strings.SplitSeq(rawNotify, ",") {
+			if cleanNotify := strings.ToUpper(strings.TrimSpace(notify)); len(cleanNotify) != 0 {
+				dsn.Notify = append(dsn.Notify, cleanNotify)
+			}
+		}
+	}
+
+	dsn.Return = strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("ret")))
+	dsn.EnvelopeID = strings.TrimSpace(r.URL.Query().Get("envid"))
+
+	return dsn
 }
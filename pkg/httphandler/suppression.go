@@ -0,0 +1,62 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+)
+
+func (s Service) HandleSuppressionList(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Authorize(bearer(r))) {
+		return
+	}
+
+	httpjson.WriteArray(r.Context(), w, http.StatusOK, s.suppressionService.List())
+}
+
+func (s Service) HandleSuppressionGet(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Authorize(bearer(r))) {
+		return
+	}
+
+	entry, err := s.suppressionService.Get(strings.TrimSpace(r.PathValue("email")), r.URL.Query().Get("category"))
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusOK, entry)
+}
+
+func (s Service) HandleSuppressionAdd(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Authorize(bearer(r))) {
+		return
+	}
+
+	entry, err := httpjson.Parse[suppression.Entry](r)
+	if err != nil {
+		httperror.BadRequest(r.Context(), w, fmt.Errorf("parse content: %w", err))
+		return
+	}
+
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Add(r.Context(), entry)) {
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s Service) HandleSuppressionDelete(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Authorize(bearer(r))) {
+		return
+	}
+
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Remove(r.Context(), strings.TrimSpace(r.PathValue("email")), r.URL.Query().Get("category"))) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/mailer"
+	"github.com/ViBiOh/mailer/pkg/store"
+)
+
+// templateFiles is the content of a template version, keyed by path relative to template's directory
+type templateFiles struct {
+	Files map[string]string `json:"files"`
+}
+
+type publishResponse struct {
+	store.Version
+	Report mailer.ValidationReport `json:"report"`
+}
+
+type publishError struct {
+	Error  string                  `json:"error"`
+	Report mailer.ValidationReport `json:"report"`
+}
+
+func (s Service) HandleReloadStatus(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusOK, s.mailerService.ReloadStatus())
+}
+
+func (s Service) HandleReload(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	if err := s.mailerService.Reload(r.Context()); err != nil {
+		httperror.HandleError(r.Context(), w, httpModel.WrapInvalid(err))
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusOK, s.mailerService.ReloadStatus())
+}
+
+func (s Service) HandleValidate(w http.ResponseWriter, r *http.Request) {
+	httpjson.Write(r.Context(), w, http.StatusOK, s.mailerService.Validate())
+}
+
+func (s Service) HandleTemplateList(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	templates, err := s.storeService.List()
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.WriteArray(r.Context(), w, http.StatusOK, templates)
+}
+
+// HandleTemplateGet gives the versions of the stored template, or the files of one version with the `version` query param
+func (s Service) HandleTemplateGet(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	name := strings.TrimSpace(r.PathValue("name"))
+
+	version, err := parseVersion(r)
+	if err != nil {
+		httperror.BadRequest(r.Context(), w, err)
+		return
+	}
+
+	if version == 0 {
+		template, err := s.storeService.Get(name)
+		if httperror.HandleError(r.Context(), w, err) {
+			return
+		}
+
+		httpjson.Write(r.Context(), w, http.StatusOK, template)
+		return
+	}
+
+	files, err := s.storeService.Files(name, version)
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusOK, templateFiles{Files: files})
+}
+
+func (s Service) HandleTemplatePut(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	payload, err := httpjson.Parse[templateFiles](r)
+	if err != nil {
+		httperror.BadRequest(r.Context(), w, fmt.Errorf("parse content: %w", err))
+		return
+	}
+
+	version, report, err := s.mailerService.Publish(r.Context(), strings.TrimSpace(r.PathValue("name")), payload.Files)
+	if errors.Is(err, mailer.ErrInvalidFiles) {
+		httpjson.Write(r.Context(), w, http.StatusBadRequest, publishError{
+			Error:  err.Error(),
+			Report: report,
+		})
+
+		return
+	}
+
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusCreated, publishResponse{
+		Version: version,
+		Report:  report,
+	})
+}
+
+// HandleTemplateDelete removes the stored template, or only one of its versions with the `version` query param
+func (s Service) HandleTemplateDelete(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	version, err := parseVersion(r)
+	if err != nil {
+		httperror.BadRequest(r.Context(), w, err)
+		return
+	}
+
+	if httperror.HandleError(r.Context(), w, s.mailerService.Unpublish(r.Context(), strings.TrimSpace(r.PathValue("name")), version)) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTemplateRollback activates the version given by the `version` query param, or the previous one
+func (s Service) HandleTemplateRollback(w http.ResponseWriter, r *http.Request) {
+	if httperror.HandleError(r.Context(), w, s.storeService.Authorize(bearer(r))) {
+		return
+	}
+
+	version, err := parseVersion(r)
+	if err != nil {
+		httperror.BadRequest(r.Context(), w, err)
+		return
+	}
+
+	name := strings.TrimSpace(r.PathValue("name"))
+
+	if httperror.HandleError(r.Context(), w, s.mailerService.Rollback(r.Context(), name, version)) {
+		return
+	}
+
+	template, err := s.storeService.Get(name)
+	if httperror.HandleError(r.Context(), w, err) {
+		return
+	}
+
+	httpjson.Write(r.Context(), w, http.StatusOK, template)
+}
+
+func parseVersion(r *http.Request) (uint, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("version"))
+	if len(raw) == 0 {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse version: %w", err)
+	}
+
+	return uint(version), nil
+}
@@ -0,0 +1,200 @@
+package httphandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/bounce"
+	"github.com/ViBiOh/mailer/pkg/mailer"
+	"github.com/ViBiOh/mailer/pkg/mjml"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
+)
+
+func newTemplatesService(t *testing.T, secret string) Service {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(dir, "hello"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hello", "hello.tmpl"), []byte("Hello {{ .Name }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	storeService, err := store.New(&store.Config{Dir: t.TempDir(), Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailerService, err := mailer.New(&mailer.Config{TemplatesDir: dir}, mjml.Service{}, nil, nil, unsubscribe.New(&unsubscribe.Config{}), storeService, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(mailerService, suppression.Service{}, unsubscribe.Service{}, bounce.Service{}, storeService, nil)
+}
+
+func TestTemplatesAuthorization(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		handler    func(Service, http.ResponseWriter, *http.Request)
+		method     string
+		body       string
+		secret     string
+		token      string
+		wantStatus int
+	}{
+		"disabled": {
+			Service.HandleTemplateList,
+			http.MethodGet,
+			"",
+			"",
+			"",
+			http.StatusForbidden,
+		},
+		"reload status unauthorized": {
+			Service.HandleReloadStatus,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"",
+			http.StatusUnauthorized,
+		},
+		"reload status": {
+			Service.HandleReloadStatus,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusOK,
+		},
+		"reload unauthorized": {
+			Service.HandleReload,
+			http.MethodPost,
+			"",
+			"s3cr3t",
+			"secret",
+			http.StatusUnauthorized,
+		},
+		"reload": {
+			Service.HandleReload,
+			http.MethodPost,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusOK,
+		},
+		"list unauthorized": {
+			Service.HandleTemplateList,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"secret",
+			http.StatusUnauthorized,
+		},
+		"list": {
+			Service.HandleTemplateList,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusOK,
+		},
+		"get unauthorized": {
+			Service.HandleTemplateGet,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"",
+			http.StatusUnauthorized,
+		},
+		"get": {
+			Service.HandleTemplateGet,
+			http.MethodGet,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusNotFound,
+		},
+		"put unauthorized": {
+			Service.HandleTemplatePut,
+			http.MethodPut,
+			`{"files":{"hello.tmpl":"Hi {{ .Name }}"}}`,
+			"s3cr3t",
+			"",
+			http.StatusUnauthorized,
+		},
+		"put": {
+			Service.HandleTemplatePut,
+			http.MethodPut,
+			`{"files":{"hello.tmpl":"Hi {{ .Name }}"}}`,
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusCreated,
+		},
+		"delete unauthorized": {
+			Service.HandleTemplateDelete,
+			http.MethodDelete,
+			"",
+			"s3cr3t",
+			"",
+			http.StatusUnauthorized,
+		},
+		"delete": {
+			Service.HandleTemplateDelete,
+			http.MethodDelete,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusNotFound,
+		},
+		"rollback unauthorized": {
+			Service.HandleTemplateRollback,
+			http.MethodPost,
+			"",
+			"s3cr3t",
+			"",
+			http.StatusUnauthorized,
+		},
+		"rollback": {
+			Service.HandleTemplateRollback,
+			http.MethodPost,
+			"",
+			"s3cr3t",
+			"s3cr3t",
+			http.StatusNotFound,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := newTemplatesService(t, testCase.secret)
+
+			request := httptest.NewRequest(testCase.method, "/templates/hello", strings.NewReader(testCase.body))
+			request.SetPathValue("name", "hello")
+			request.Header.Set("Content-Type", "application/json")
+
+			if len(testCase.token) != 0 {
+				request.Header.Set("Authorization", "Bearer "+testCase.token)
+			}
+
+			writer := httptest.NewRecorder()
+			testCase.handler(instance, writer, request)
+
+			if writer.Code != testCase.wantStatus {
+				t.Errorf("handler() = %d, want %d: %s", writer.Code, testCase.wantStatus, writer.Body.String())
+			}
+		})
+	}
+}
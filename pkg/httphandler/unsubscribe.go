@@ -0,0 +1,76 @@
+package httphandler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/suppression"
+)
+
+var unsubscribeTemplate = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>Unsubscribe</title>
+  </head>
+  <body>
+    {{ if .Done }}
+      <p>{{ .Recipient }} has been unsubscribed{{ with .Category }} from {{ . }}{{ end }}.</p>
+    {{ else }}
+      <form method="post">
+        <p>Unsubscribe {{ .Recipient }}{{ with .Category }} from {{ . }}{{ end }}?</p>
+        <button type="submit">Unsubscribe</button>
+      </form>
+    {{ end }}
+  </body>
+</html>
+`))
+
+type unsubscribePage struct {
+	Recipient string
+	Category  string
+	Done      bool
+}
+
+// HandleUnsubscribe displays a confirmation page, links may be prefetched by mail clients so GET never unsubscribes
+func (s Service) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	recipient, category, err := s.unsubscribeService.Parse(r.URL.Query().Get("token"))
+	if err != nil {
+		httperror.HandleError(r.Context(), w, httpModel.WrapInvalid(err))
+		return
+	}
+
+	writeUnsubscribePage(w, r, unsubscribePage{Recipient: recipient, Category: category})
+}
+
+// HandleUnsubscribePost records the opt-out, as described in RFC 8058 for one-click unsubscribe
+func (s Service) HandleUnsubscribePost(w http.ResponseWriter, r *http.Request) {
+	recipient, category, err := s.unsubscribeService.Parse(r.URL.Query().Get("token"))
+	if err != nil {
+		httperror.HandleError(r.Context(), w, httpModel.WrapInvalid(err))
+		return
+	}
+
+	if httperror.HandleError(r.Context(), w, s.suppressionService.Add(r.Context(), suppression.Entry{
+		Email:    recipient,
+		Category: category,
+		Reason:   suppression.ReasonUnsubscribe,
+	})) {
+		return
+	}
+
+	writeUnsubscribePage(w, r, unsubscribePage{Recipient: recipient, Category: category, Done: true})
+}
+
+func writeUnsubscribePage(w http.ResponseWriter, r *http.Request, page unsubscribePage) {
+	w.Header().Add("Content-Type", "text/html; charset=UTF-8")
+	w.Header().Add("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if err := unsubscribeTemplate.Execute(w, page); err != nil {
+		httperror.InternalServerError(r.Context(), w, fmt.Errorf("render unsubscribe page: %w", err))
+	}
+}
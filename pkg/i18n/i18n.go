@@ -0,0 +1,218 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+const catalogExtension = ".json"
+
+var forms = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// Message is a translated string, with a variant for each plural form, `other` being the default one
+type Message map[string]string
+
+func (m *Message) UnmarshalJSON(content []byte) error {
+	var single string
+	if err := json.Unmarshal(content, &single); err == nil {
+		*m = Message{"other": single}
+		return nil
+	}
+
+	var plurals map[string]string
+	if err := json.Unmarshal(content, &plurals); err != nil {
+		return fmt.Errorf("message must be a string or an object of plural forms: %w", err)
+	}
+
+	*m = plurals
+
+	return nil
+}
+
+// Catalogs contains messages by key, for each locale
+type Catalogs map[string]map[string]Message
+
+// Load reads every `<locale>.json` file of the directory, a missing directory giving no catalog
+func Load(fsys fs.FS, dir string) (Catalogs, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read catalogs directory: %w", err)
+	}
+
+	catalogs := make(Catalogs)
+
+	for _, file := range files {
+		locale, ok := strings.CutSuffix(file.Name(), catalogExtension)
+		if !ok || file.IsDir() {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read catalog `%s`: %w", locale, err)
+		}
+
+		var messages map[string]Message
+		if err := json.Unmarshal(content, &messages); err != nil {
+			return nil, fmt.Errorf("parse catalog `%s`: %w", locale, err)
+		}
+
+		catalogs[Normalize(locale)] = messages
+	}
+
+	return catalogs, nil
+}
+
+// Normalize returns the canonical form of a BCP 47 locale, e.g. `fr_ca` gives `fr-CA`
+func Normalize(locale string) string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if len(locale) == 0 {
+		return ""
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return locale
+	}
+
+	return tag.String()
+}
+
+// Fallbacks lists the locale followed by its parents, from the most specific to the most generic, e.g. `fr-CA` gives `fr-CA` then `fr`
+func Fallbacks(locale string) []string {
+	locale = Normalize(locale)
+	if len(locale) == 0 {
+		return nil
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return []string{locale}
+	}
+
+	var output []string
+	for ; !tag.IsRoot(); tag = tag.Parent() {
+		output = append(output, tag.String())
+	}
+
+	return output
+}
+
+// Translate finds the message of the first locale having the key. When arguments are given, the first one selects the plural form and all are used for formatting the message
+func (c Catalogs) Translate(locales []string, key string, args ...any) string {
+	for _, locale := range locales {
+		message, ok := c[locale][key]
+		if !ok {
+			continue
+		}
+
+		content := message.form(locale, args)
+		if len(args) == 0 || !strings.Contains(content, "%") {
+			return content
+		}
+
+		return fmt.Sprintf(content, integers(args)...)
+	}
+
+	return key
+}
+
+func (m Message) form(locale string, args []any) string {
+	if len(args) == 0 {
+		return m["other"]
+	}
+
+	count, ok := toInt(args[0])
+	if !ok {
+		return m["other"]
+	}
+
+	if content, ok := m["zero"]; ok && count == 0 {
+		return content
+	}
+
+	if content, ok := m[Plural(locale, count)]; ok {
+		return content
+	}
+
+	return m["other"]
+}
+
+// Plural gives the CLDR plural form of the count in the locale: zero, one, two, few, many or other
+func Plural(locale string, count int) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.English
+	}
+
+	if count < 0 {
+		count = -count
+	}
+
+	return forms[plural.Cardinal.MatchPlural(tag, count, 0, 0, 0, 0)]
+}
+
+// integers converts whole floats, as decoded from JSON payloads, for being formatted with `%d`
+func integers(args []any) []any {
+	output := make([]any, len(args))
+
+	for index, arg := range args {
+		if number, ok := arg.(float64); ok && number == math.Trunc(number) {
+			arg = int(number)
+		}
+
+		output[index] = arg
+	}
+
+	return output
+}
+
+func toInt(value any) (int, bool) {
+	switch number := value.(type) {
+	case int:
+		return number, true
+	case int8:
+		return int(number), true
+	case int16:
+		return int(number), true
+	case int32:
+		return int(number), true
+	case int64:
+		return int(number), true
+	case uint:
+		return int(number), true
+	case uint8:
+		return int(number), true
+	case uint16:
+		return int(number), true
+	case uint32:
+		return int(number), true
+	case uint64:
+		return int(number), true
+	case float32:
+		return int(number), true
+	case float64:
+		return int(number), true
+	default:
+		return 0, false
+	}
+}
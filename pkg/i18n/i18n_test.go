@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestFallbacks(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		locale string
+		want   []string
+	}{
+		"empty": {
+			"",
+			nil,
+		},
+		"language": {
+			"fr",
+			[]string{"fr"},
+		},
+		"region": {
+			"fr_ca",
+			[]string{"fr-CA", "fr"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Fallbacks(testCase.locale); !slices.Equal(got, testCase.want) {
+				t.Errorf("Fallbacks() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	var catalogs Catalogs
+	if err := json.Unmarshal([]byte(`{
+		"en": {"hello": "Hello", "releases": {"zero": "No release", "one": "%d release", "other": "%d releases"}},
+		"fr": {"hello": "Bonjour", "releases": {"one": "%d nouvelle version", "other": "%d nouvelles versions"}},
+		"fr-CA": {"hello": "Allô"}
+	}`), &catalogs); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		key     string
+		locales []string
+		args    []any
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"simple": {
+			args{key: "hello", locales: []string{"en"}},
+			"Hello",
+		},
+		"specific": {
+			args{key: "hello", locales: Fallbacks("fr-CA")},
+			"Allô",
+		},
+		"fallback": {
+			args{key: "releases", locales: Fallbacks("fr-CA"), args: []any{1}},
+			"1 nouvelle version",
+		},
+		"plural": {
+			args{key: "releases", locales: []string{"en"}, args: []any{float64(3)}},
+			"3 releases",
+		},
+		"zero": {
+			args{key: "releases", locales: []string{"en"}, args: []any{0}},
+			"No release",
+		},
+		"french zero": {
+			args{key: "releases", locales: []string{"fr"}, args: []any{0}},
+			"0 nouvelle version",
+		},
+		"unknown": {
+			args{key: "goodbye", locales: []string{"fr", "en"}},
+			"goodbye",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := catalogs.Translate(testCase.args.locales, testCase.args.key, testCase.args.args...); got != testCase.want {
+				t.Errorf("Translate() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
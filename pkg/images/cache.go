@@ -0,0 +1,81 @@
+package images
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	image     Image
+	expiresAt time.Time
+}
+
+// cache keeps fetched images in memory for a while, images being the same for every mail of a template
+type cache struct {
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	size    int
+	ttl     time.Duration
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+
+	return &cache{
+		entries: make(map[string]cacheEntry),
+		size:    size,
+		ttl:     ttl,
+	}
+}
+
+func (c *cache) get(url string) (Image, bool) {
+	if c == nil {
+		return Image{}, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[url]
+	if !ok {
+		return Image{}, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, url)
+		return Image{}, false
+	}
+
+	return entry.image, true
+}
+
+// set keeps the image, unless the cache is full of unexpired ones
+func (c *cache) set(url string, image Image) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+
+	if len(c.entries) >= c.size {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+
+	c.entries[url] = cacheEntry{
+		image:     image,
+		expiresAt: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,235 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/request"
+	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrNotImage       = errors.New("content is not an image")
+	ErrNotAllowed     = errors.New("url is not in the allowlist")
+	ErrPrivateAddress = errors.New("address is private")
+)
+
+// Image is the content of an image to embed in a mail
+type Image struct {
+	ContentType string
+	Content     []byte
+}
+
+// ContentType gives the type of the image, from the given one if any, else from its content
+func ContentType(given string, content []byte) (string, error) {
+	contentType := given
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(content)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("parse content type: %w", err)
+	}
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("`%s`: %w", mediaType, ErrNotImage)
+	}
+
+	return mediaType, nil
+}
+
+type Service struct {
+	tracer    trace.Tracer
+	cache     *cache
+	client    *http.Client
+	allowlist []string
+	timeout   time.Duration
+	maxSize   int64
+}
+
+type Config struct {
+	Allowlist []string
+	Timeout   time.Duration
+	MaxSize   int
+	CacheSize int
+	CacheTTL  time.Duration
+}
+
+func Flags(fs *flag.FlagSet, prefix string) *Config {
+	var config Config
+
+	flags.New("Allowlist", "Hosts (e.g. cdn.vibioh.fr) or URL prefixes (e.g. https://vibioh.fr/images/) images can be fetched from, none if empty").Prefix(prefix).DocPrefix("images").StringSliceVar(fs, &config.Allowlist, nil, nil)
+	flags.New("Timeout", "Timeout of fetching an image to embed").Prefix(prefix).DocPrefix("images").DurationVar(fs, &config.Timeout, 5*time.Second, nil)
+	flags.New("MaxSize", "Maximum size in bytes of a fetched image").Prefix(prefix).DocPrefix("images").IntVar(fs, &config.MaxSize, 1024*1024, nil)
+	flags.New("CacheSize", "Number of fetched images kept in memory, 0 to disable").Prefix(prefix).DocPrefix("images").IntVar(fs, &config.CacheSize, 50, nil)
+	flags.New("CacheTTL", "Duration of keeping a fetched image in memory").Prefix(prefix).DocPrefix("images").DurationVar(fs, &config.CacheTTL, time.Hour, nil)
+
+	return &config
+}
+
+func New(config *Config, tracerProvider trace.TracerProvider) Service {
+	var allowlist []string
+	for _, allowed := range config.Allowlist {
+		if cleanAllowed := strings.ToLower(strings.TrimSpace(allowed)); len(cleanAllowed) != 0 {
+			allowlist = append(allowlist, cleanAllowed)
+		}
+	}
+
+	service := Service{
+		cache:     newCache(config.CacheSize, config.CacheTTL),
+		allowlist: allowlist,
+		timeout:   config.Timeout,
+		maxSize:   int64(config.MaxSize),
+	}
+
+	service.client = service.newClient(rejectPrivate)
+
+	if tracerProvider != nil {
+		service.tracer = tracerProvider.Tracer("images")
+	}
+
+	return service
+}
+
+// Fetch downloads the image at the URL, or gives the cached one if fetched recently
+func (s Service) Fetch(ctx context.Context, url string) (Image, error) {
+	if image, ok := s.cache.get(url); ok {
+		return image, nil
+	}
+
+	if !s.Allowed(url) {
+		return Image{}, ErrNotAllowed
+	}
+
+	var err error
+
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "fetch")
+	defer end(&err)
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	resp, err := request.Get(url).WithClient(s.client).Send(ctx, nil)
+	if err != nil {
+		return Image{}, fmt.Errorf("fetch: %w", err)
+	}
+
+	image, err := s.read(resp)
+	if err != nil {
+		return image, err
+	}
+
+	s.cache.set(url, image)
+
+	return image, nil
+}
+
+// Allowed checks if the URL is on an allowed host or starts with an allowed prefix
+func (s Service) Allowed(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+
+	lowerURL := strings.ToLower(rawURL)
+	host := strings.ToLower(parsed.Hostname())
+
+	for _, allowed := range s.allowlist {
+		if strings.Contains(allowed, "://") {
+			if strings.HasPrefix(lowerURL, allowed) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newClient doesn't follow redirects outside the allowlist, and checks with control every address it connects to
+func (s Service) newClient(control func(network, address string, conn syscall.RawConn) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: control,
+	}).DialContext
+
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+
+			if !s.Allowed(req.URL.String()) {
+				return ErrNotAllowed
+			}
+
+			return nil
+		},
+	}
+}
+
+// rejectPrivate prevents connecting to a loopback, private or link-local address, whatever the host resolves to
+func rejectPrivate(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("parse address: %w", err)
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsUnspecified() || addr.IsMulticast() {
+		return fmt.Errorf("`%s`: %w", addr, ErrPrivateAddress)
+	}
+
+	return nil
+}
+
+func (s Service) read(resp *http.Response) (Image, error) {
+	defer func() {
+		_ = request.DiscardBody(resp.Body)
+	}()
+
+	reader := resp.Body
+	if s.maxSize > 0 {
+		reader = io.NopCloser(io.LimitReader(resp.Body, s.maxSize+1))
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return Image{}, fmt.Errorf("read: %w", err)
+	}
+
+	if s.maxSize > 0 && int64(len(content)) > s.maxSize {
+		return Image{}, fmt.Errorf("image is above %d bytes", s.maxSize)
+	}
+
+	contentType, err := ContentType(resp.Header.Get("Content-Type"), content)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		switch r.URL.Path {
+		case "/logo.png":
+			_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		case "/large.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(make([]byte, 64))
+		case "/redirect.png":
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/logo.png", http.StatusFound)
+		case "/page.html":
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	instance := New(&Config{Allowlist: []string{server.URL + "/"}, Timeout: time.Second, MaxSize: 32, CacheSize: 10, CacheTTL: time.Minute}, nil)
+
+	t.Run("private address", func(t *testing.T) {
+		if _, err := instance.Fetch(context.Background(), server.URL+"/logo.png"); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("Fetch() = `%v`, want `%s`", err, ErrPrivateAddress)
+		}
+	})
+
+	// Test server listens on loopback
+	instance.client = instance.newClient(nil)
+
+	cases := map[string]struct {
+		url             string
+		wantContentType string
+		wantErr         bool
+	}{
+		"image": {
+			server.URL + "/logo.png",
+			"image/png",
+			false,
+		},
+		"not allowed": {
+			"http://example.com/logo.png",
+			"",
+			true,
+		},
+		"redirect outside allowlist": {
+			server.URL + "/redirect.png",
+			"",
+			true,
+		},
+		"too large": {
+			server.URL + "/large.png",
+			"",
+			true,
+		},
+		"not an image": {
+			server.URL + "/page.html",
+			"",
+			true,
+		},
+		"not found": {
+			server.URL + "/missing.png",
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			image, err := instance.Fetch(context.Background(), testCase.url)
+			if gotErr := err != nil; gotErr != testCase.wantErr {
+				t.Errorf("Fetch() = `%v`, want error %t", err, testCase.wantErr)
+			}
+
+			if image.ContentType != testCase.wantContentType {
+				t.Errorf("Fetch() = `%s`, want `%s`", image.ContentType, testCase.wantContentType)
+			}
+		})
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		before := calls.Load()
+
+		if _, err := instance.Fetch(context.Background(), server.URL+"/logo.png"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := instance.Fetch(context.Background(), server.URL+"/logo.png"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := calls.Load() - before; got > 1 {
+			t.Errorf("Fetch() called the server %d times, want at most once", got)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package jsonfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Load reads the items stored in the file, none if the file is not configured or doesn't exist yet
+func Load[T any](file string) ([]T, error) {
+	if len(file) == 0 {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read file `%s`: %w", file, err)
+	}
+
+	var items []T
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil, fmt.Errorf("parse file `%s`: %w", file, err)
+	}
+
+	return items, nil
+}
+
+// Save replaces atomically the items stored in the file, nothing being done if the file is not configured
+func Save[T any](file string, items []T) error {
+	if len(file) == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	tempFile := file + ".tmp"
+	if err := os.WriteFile(tempFile, content, 0o600); err != nil {
+		return fmt.Errorf("write file `%s`: %w", tempFile, err)
+	}
+
+	if err := os.Rename(tempFile, file); err != nil {
+		return fmt.Errorf("rename file `%s`: %w", tempFile, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package jsonfile
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	cases := map[string]struct {
+		file    string
+		items   []string
+		want    []string
+		wantErr bool
+	}{
+		"persisted": {
+			filepath.Join(dir, "items.json"),
+			[]string{"first", "second"},
+			[]string{"first", "second"},
+			false,
+		},
+		"in memory": {
+			"",
+			[]string{"first"},
+			nil,
+			false,
+		},
+		"unwritable": {
+			filepath.Join(dir, "missing", "items.json"),
+			[]string{"first"},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if err := Save(testCase.file, testCase.items); (err != nil) != testCase.wantErr {
+				t.Errorf("Save() = %v, want error %t", err, testCase.wantErr)
+			}
+
+			got, err := Load[string](testCase.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, testCase.want) {
+				t.Errorf("Load() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
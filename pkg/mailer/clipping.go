@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	mailer_metric "github.com/ViBiOh/mailer/pkg/metric"
+	"github.com/ViBiOh/mailer/pkg/minify"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ClippingError is returned when the rendered HTML is larger than the size above which Gmail clips it, hiding the footer
+type ClippingError struct {
+	Template string
+	Size     int
+	Limit    int
+}
+
+func (ce ClippingError) Error() string {
+	return fmt.Sprintf("template `%s` is %d bytes, above %d bytes clipped by Gmail", ce.Template, ce.Size, ce.Limit)
+}
+
+// isMinified checks if rendered HTML of template is minified, template's metadata overriding global configuration
+func (s Service) isMinified(state *templates, templateName string) bool {
+	if minified := state.metadata[baseName(templateName)].Minify; minified != nil {
+		return *minified
+	}
+
+	return s.minify
+}
+
+func minifyHTML(content *bytes.Buffer) error {
+	output, err := minify.HTML(content.Bytes())
+	if err != nil {
+		return fmt.Errorf("minify: %w", err)
+	}
+
+	content.Reset()
+	content.Write(output)
+
+	return nil
+}
+
+// checkClipping reports the rendered HTML if it's above the clipping size, failing if configured so
+func (s Service) checkClipping(ctx context.Context, templateName string, size int) (bool, error) {
+	if s.clippingSize <= 0 || size <= s.clippingSize {
+		return false, nil
+	}
+
+	mailer_metric.Increase(ctx, "render", "clipped", attribute.String("template", baseName(templateName)))
+
+	err := ClippingError{Template: templateName, Size: size, Limit: s.clippingSize}
+
+	if s.clippingFail {
+		return true, httpModel.WrapInvalid(err)
+	}
+
+	slog.LogAttrs(ctx, slog.LevelWarn, "rendered mail will be clipped", slog.String("template", templateName), slog.Int("size", size), slog.Int("limit", s.clippingSize))
+
+	return true, nil
+}
@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestRenderClipping(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "hello"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hello", "hello.tmpl"), []byte("<div>\n    <p>Hello {{ .Name }}</p>\n</div>\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		config      Config
+		wantSize    int
+		wantClipped bool
+		wantErr     bool
+	}{
+		"raw": {
+			Config{ClippingSize: 100},
+			37,
+			false,
+			false,
+		},
+		"minified": {
+			Config{Minify: true, ClippingSize: 20},
+			27,
+			true,
+			false,
+		},
+		"fail": {
+			Config{ClippingSize: 20, ClippingFail: true},
+			37,
+			true,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			config := testCase.config
+			config.TemplatesDir = dir
+
+			instance := newTestService(t, config)
+
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template("hello").Data(map[string]any{"Name": "Bob"}))
+
+			var clippingErr ClippingError
+			if gotErr := errors.As(err, &clippingErr); gotErr != testCase.wantErr {
+				t.Errorf("Render() = `%v`, want clipping error %t", err, testCase.wantErr)
+			}
+
+			if output.Size != testCase.wantSize || output.Clipped != testCase.wantClipped {
+				t.Errorf("Render() = (%d, %t), want (%d, %t)", output.Size, output.Clipped, testCase.wantSize, testCase.wantClipped)
+			}
+		})
+	}
+}
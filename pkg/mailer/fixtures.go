@@ -3,26 +3,27 @@ package mailer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
-	"os"
 	"path"
 	"strings"
 
 	"github.com/ViBiOh/httputils/v4/pkg/model"
 )
 
-func (s Service) getTemplatePath(templateName string) string {
-	return path.Join(s.templatesDir, templateName)
+func getFixturePath(templateName, fixtureName string) string {
+	return path.Join(templateName, fmt.Sprintf("%s%s", fixtureName, jsonExtension))
 }
 
-func (s Service) getFixturePath(templateName, fixtureName string) string {
-	return path.Join(s.templatesDir, templateName, fmt.Sprintf("%s%s", fixtureName, jsonExtension))
-}
+func isExists(fsys fs.FS, path string, directory bool) error {
+	if fsys == nil {
+		return model.ErrNotFound
+	}
 
-func isExists(path string, directory bool) error {
-	if info, err := os.Stat(path); err != nil {
-		if os.IsNotExist(err) {
+	if info, err := fs.Stat(fsys, path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
 			return model.ErrNotFound
 		}
 		return err
@@ -33,20 +34,28 @@ func isExists(path string, directory bool) error {
 	return nil
 }
 
+// isFixture checks that JSON file is not one of the template's configuration files
+func isFixture(name string) bool {
+	return name != metadataFilename && name != schemaFilename
+}
+
 func (s Service) ListFixtures(name string) ([]string, error) {
-	templatePath := s.getTemplatePath(name)
-	if err := isExists(templatePath, true); err != nil {
+	return listFixtures(s.filesystem(), name)
+}
+
+func listFixtures(fsys fs.FS, name string) ([]string, error) {
+	if err := isExists(fsys, name, true); err != nil {
 		return nil, err
 	}
 
-	files, err := os.ReadDir(templatePath)
+	files, err := fs.ReadDir(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("read templates directory: %w", err)
 	}
 
 	var fixtureList []string
 	for _, file := range files {
-		if before, ok := strings.CutSuffix(file.Name(), jsonExtension); ok {
+		if before, ok := strings.CutSuffix(file.Name(), jsonExtension); ok && isFixture(before) {
 			fixtureList = append(fixtureList, before)
 		}
 	}
@@ -55,17 +64,24 @@ func (s Service) ListFixtures(name string) ([]string, error) {
 }
 
 func (s Service) GetFixture(name, fixture string) (map[string]any, error) {
-	templatePath := s.getTemplatePath(name)
-	if err := isExists(templatePath, true); err != nil {
-		return nil, fmt.Errorf("template exists `%s`: %w", templatePath, err)
+	return getFixture(s.filesystem(), name, fixture)
+}
+
+func getFixture(fsys fs.FS, name, fixture string) (map[string]any, error) {
+	if !isFixture(fixture) {
+		return nil, fmt.Errorf("fixture `%s`: %w", fixture, model.ErrNotFound)
+	}
+
+	if err := isExists(fsys, name, true); err != nil {
+		return nil, fmt.Errorf("template exists `%s`: %w", name, err)
 	}
 
-	fixturePath := s.getFixturePath(name, fixture)
-	if err := isExists(fixturePath, false); err != nil {
+	fixturePath := getFixturePath(name, fixture)
+	if err := isExists(fsys, fixturePath, false); err != nil {
 		return nil, fmt.Errorf("fixture exists `%s`: %w", fixturePath, err)
 	}
 
-	reader, err := os.OpenFile(fixturePath, os.O_RDONLY, 0o600)
+	reader, err := fsys.Open(fixturePath)
 	if err != nil {
 		return nil, fmt.Errorf("open file `%s`: %w", fixturePath, err)
 	}
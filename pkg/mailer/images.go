@@ -0,0 +1,163 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/mailer/pkg/images"
+	"github.com/ViBiOh/mailer/pkg/model"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const contentIDDomain = "mailer"
+
+var errNotEmbeddable = errors.New("image can't be embedded")
+
+type imageFetcher interface {
+	Fetch(ctx context.Context, url string) (images.Image, error)
+}
+
+// isEmbeddingImages checks if images of template are embedded in the mail, template's metadata overriding global configuration
+func (s Service) isEmbeddingImages(state *templates, templateName string) bool {
+	if embed := state.metadata[baseName(templateName)].EmbedImages; embed != nil {
+		return *embed
+	}
+
+	return s.embedImages
+}
+
+// embed replaces the source of images, from the templates directory or fetched, by a `cid:` reference to an inline part of the mail. An image that can't be embedded is kept as is.
+func (s Service) embed(ctx context.Context, fsys fs.FS, content *bytes.Buffer) ([]model.Inline, error) {
+	tokenizer := html.NewTokenizer(bytes.NewReader(content.Bytes()))
+
+	var output bytes.Buffer
+	var inlines []model.Inline
+	contentIDs := make(map[string]string)
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("tokenize: %w", err)
+			}
+
+			content.Reset()
+			content.Write(output.Bytes())
+
+			return inlines, nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := append([]byte(nil), tokenizer.Raw()...)
+			token := tokenizer.Token()
+
+			index := slices.IndexFunc(token.Attr, func(attr html.Attribute) bool { return attr.Key == "src" })
+			if token.DataAtom != atom.Img || index == -1 {
+				output.Write(raw)
+				continue
+			}
+
+			source := token.Attr[index].Val
+
+			contentID, ok := contentIDs[source]
+			if !ok {
+				inline, err := s.loadImage(ctx, fsys, source)
+				if err != nil && !errors.Is(err, errNotEmbeddable) {
+					slog.LogAttrs(ctx, slog.LevelWarn, "embed image", slog.String("source", source), slog.Any("error", err))
+				}
+
+				if err == nil {
+					contentID = inline.ContentID
+
+					if !slices.ContainsFunc(inlines, func(item model.Inline) bool { return item.ContentID == contentID }) {
+						inlines = append(inlines, inline)
+					}
+				}
+
+				contentIDs[source] = contentID
+			}
+
+			if len(contentID) == 0 {
+				output.Write(raw)
+				continue
+			}
+
+			token.Attr[index].Val = "cid:" + contentID
+			output.WriteString(token.String())
+
+		default:
+			output.Write(tokenizer.Raw())
+		}
+	}
+}
+
+func (s Service) loadImage(ctx context.Context, fsys fs.FS, source string) (model.Inline, error) {
+	var image images.Image
+	var filename string
+
+	switch {
+	case strings.HasPrefix(source, "https://"), strings.HasPrefix(source, "http://"):
+		if s.imageFetcher == nil {
+			return model.Inline{}, errNotEmbeddable
+		}
+
+		parsed, err := url.Parse(source)
+		if err != nil {
+			return model.Inline{}, fmt.Errorf("parse url: %w", err)
+		}
+
+		if image, err = s.imageFetcher.Fetch(ctx, source); errors.Is(err, images.ErrNotAllowed) {
+			return model.Inline{}, errNotEmbeddable
+		} else if err != nil {
+			return model.Inline{}, err
+		}
+
+		filename = path.Base(parsed.Path)
+
+	case strings.Contains(source, ":"), strings.HasPrefix(source, "//"):
+		// e.g. `cid:` or `data:` ones
+		return model.Inline{}, errNotEmbeddable
+
+	default:
+		name := path.Clean(strings.TrimPrefix(source, "/"))
+		if !fs.ValidPath(name) {
+			return model.Inline{}, errNotEmbeddable
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return model.Inline{}, fmt.Errorf("read: %w", err)
+		}
+
+		contentType, err := images.ContentType(mime.TypeByExtension(path.Ext(name)), content)
+		if err != nil {
+			return model.Inline{}, err
+		}
+
+		image = images.Image{ContentType: contentType, Content: content}
+		filename = path.Base(name)
+	}
+
+	hash := sha256.Sum256(image.Content)
+
+	return model.Inline{
+		ContentID:   hex.EncodeToString(hash[:8]) + "@" + contentIDDomain,
+		ContentType: image.ContentType,
+		Filename:    filename,
+		Content:     image.Content,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/images"
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+// png is the signature of a PNG file, enough for detecting its content type
+var png = []byte("\x89PNG\r\n\x1a\n")
+
+type fakeFetcher map[string]images.Image
+
+func (ff fakeFetcher) Fetch(_ context.Context, url string) (images.Image, error) {
+	if image, ok := ff[url]; ok {
+		return image, nil
+	}
+
+	return images.Image{}, errors.New("not found")
+}
+
+func TestRenderEmbedImages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"images/logo.png":  string(png),
+		"images/notes.txt": "not an image",
+		"hello/hello.tmpl": `<img src="images/logo.png"><img src="/images/logo.png" alt="again"><img src="https://example.com/banner.png"><img src="https://example.com/missing.png"><img src="images/notes.txt"><img src="data:image/png;base64,AA==">`,
+		"hello/meta.json":  `{"embedImages": true}`,
+		"plain/plain.tmpl": `<img src="images/logo.png">`,
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fetcher := fakeFetcher{"https://example.com/banner.png": {ContentType: "image/png", Content: append(png, 'b')}}
+
+	instance := newTestService(t, Config{TemplatesDir: dir}, func(dependencies *testDependencies) { dependencies.imageFetcher = fetcher })
+
+	cases := map[string]struct {
+		template    string
+		wantInlines []string
+	}{
+		"embedded": {
+			"hello",
+			[]string{"logo.png", "banner.png"},
+		},
+		"disabled": {
+			"plain",
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template(testCase.template))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := io.ReadAll(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var filenames []string
+			for _, inline := range output.Inlines {
+				filenames = append(filenames, inline.Filename)
+
+				if count := strings.Count(string(content), `"cid:`+inline.ContentID+`"`); count == 0 {
+					t.Errorf("Render() = `%s`, want `%s` referenced", content, inline.ContentID)
+				}
+			}
+
+			if strings.Join(filenames, ",") != strings.Join(testCase.wantInlines, ",") {
+				t.Errorf("Render() embedded %v, want %v", filenames, testCase.wantInlines)
+			}
+		})
+	}
+}
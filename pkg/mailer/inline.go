@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ViBiOh/mailer/pkg/css"
+	"github.com/ViBiOh/mailer/pkg/mjml"
+)
+
+// isInlined checks if CSS of template is moved in style attributes, template's metadata overriding global configuration. A precompiled MJML template is already inlined, and its conditional comments wouldn't survive parsing again.
+func (s Service) isInlined(state *templates, templateName string, set templateSet) bool {
+	if set.precompiled {
+		return false
+	}
+
+	if inline := state.metadata[baseName(templateName)].InlineCSS; inline != nil {
+		return *inline
+	}
+
+	return s.inlineCSS
+}
+
+// inlineCSS moves stylesheet rules of HTML templates in style attributes, MJML already doing it on conversion
+func inlineCSS(content *bytes.Buffer) error {
+	if mjml.IsMJML(content.Bytes()) {
+		return nil
+	}
+
+	output, err := css.Inline(content.Bytes())
+	if err != nil {
+		return fmt.Errorf("inline css: %w", err)
+	}
+
+	content.Reset()
+	content.Write(output)
+
+	return nil
+}
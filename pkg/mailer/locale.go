@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/ViBiOh/mailer/pkg/i18n"
+)
+
+const localesDirname = "locales"
+
+// splitLocale separates template's name from its locale, e.g. `hello.fr-CA` gives `hello` and `fr-CA`
+func splitLocale(name string) (string, string) {
+	base, locale, _ := strings.Cut(name, ".")
+	return base, locale
+}
+
+func loadCatalogs(fsys fs.FS, templateName string) (i18n.Catalogs, error) {
+	catalogs, err := i18n.Load(fsys, path.Join(templateName, localesDirname))
+	if err != nil {
+		return nil, fmt.Errorf("catalogs of `%s`: %w", templateName, err)
+	}
+
+	return catalogs, nil
+}
+
+// localizedSet is a template bound to the translations of a locale
+type localizedSet struct {
+	tpl    *template.Template
+	strict *template.Template
+}
+
+// supportedLocales lists the locales having translations or a localized template, with the default one, none if the template isn't localized
+func (s Service) supportedLocales(state *templates, templateName string) []string {
+	base := baseName(templateName)
+
+	locales := slices.Collect(maps.Keys(state.catalogs[base]))
+	locales = append(locales, state.locales[base]...)
+
+	if len(locales) == 0 {
+		return nil
+	}
+
+	if len(s.defaultLocale) != 0 {
+		locales = append(locales, s.defaultLocale)
+	}
+
+	slices.Sort(locales)
+
+	return slices.Compact(locales)
+}
+
+// localizeSet clones the template for each supported locale, binding its translations, so rendering doesn't have to
+func (s Service) localizeSet(state *templates, templateName string, set templateSet) (templateSet, error) {
+	locales := s.supportedLocales(state, templateName)
+	if len(locales) == 0 {
+		return set, nil
+	}
+
+	catalogs := state.catalogs[baseName(templateName)]
+	set.localized = make(map[string]localizedSet, len(locales))
+
+	for _, locale := range locales {
+		funcs := template.FuncMap{
+			"locale": func() string {
+				return locale
+			},
+			"t": func(key string, args ...any) string {
+				return catalogs.Translate(append(i18n.Fallbacks(locale), i18n.Fallbacks(s.defaultLocale)...), key, args...)
+			},
+		}
+
+		tpl, err := set.tpl.Clone()
+		if err != nil {
+			return set, fmt.Errorf("clone `%s`: %w", locale, err)
+		}
+
+		strict, err := set.strict.Clone()
+		if err != nil {
+			return set, fmt.Errorf("clone strict `%s`: %w", locale, err)
+		}
+
+		set.localized[locale] = localizedSet{
+			tpl:    tpl.Funcs(funcs),
+			strict: strict.Funcs(funcs),
+		}
+	}
+
+	return set, nil
+}
+
+// lookupSet finds the most specific set of the template for the locale, falling back to the default one
+func lookupSet(state *templates, templateName, locale string) (templateSet, bool) {
+	set, ok := state.sets[templateName]
+	for _, candidate := range i18n.Fallbacks(locale) {
+		if candidateSet, found := state.sets[templateName+"."+candidate]; found {
+			return candidateSet, true
+		}
+	}
+
+	return set, ok
+}
+
+// localize gives the template of the set bound to the translations of the most specific supported locale
+func (s Service) localize(set templateSet, locale string, strict bool) *template.Template {
+	for _, candidate := range append(i18n.Fallbacks(locale), i18n.Fallbacks(s.defaultLocale)...) {
+		if localized, found := set.localized[candidate]; found {
+			if strict {
+				return localized.strict
+			}
+
+			return localized.tpl
+		}
+	}
+
+	if strict {
+		return set.strict
+	}
+
+	return set.tpl
+}
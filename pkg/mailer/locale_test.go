@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"hello/hello.tmpl":      `{{ t "greeting" }} {{ .Name }}, {{ t "messages" .Count }}`,
+		"hello/hello.fr.tmpl":   `{{ t "greeting" }} {{ .Name }}, vous avez {{ t "messages" .Count }}`,
+		"hello/locales/en.json": `{"greeting": "Hello", "messages": {"one": "%d message", "other": "%d messages"}}`,
+		"hello/locales/fr.json": `{"greeting": "Bonjour", "messages": {"one": "%d message", "other": "%d messages"}}`,
+		"hello/locales/de.json": `{"greeting": "Hallo"}`,
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir, DefaultLocale: "en"})
+
+	if got := len(instance.templates.Load().sets["hello"].localized); got != 3 {
+		t.Errorf("load() localized %d locales, want 3", got)
+	}
+
+	cases := map[string]struct {
+		locale string
+		count  float64
+		want   string
+	}{
+		"default": {
+			"",
+			1,
+			"Hello Bob, 1 message",
+		},
+		"region fallback": {
+			"fr-CA",
+			0,
+			"Bonjour Bob, vous avez 0 message",
+		},
+		"catalog only": {
+			"de",
+			2,
+			"Hallo Bob, 2 messages",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template("hello").WithLocale(testCase.locale).Data(map[string]any{"Name": "Bob", "Count": testCase.count}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != testCase.want {
+				t.Errorf("Render() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
@@ -4,23 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
-	"path"
-	"path/filepath"
+	"slices"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 
 	"github.com/ViBiOh/flags"
 	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	"github.com/ViBiOh/mailer/pkg/funcs"
 	mailer_metric "github.com/ViBiOh/mailer/pkg/metric"
 	"github.com/ViBiOh/mailer/pkg/mjml"
 	"github.com/ViBiOh/mailer/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/smtp"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/suppression"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
@@ -30,73 +37,155 @@ type sender interface {
 	Send(ctx context.Context, mail model.Mail) error
 }
 
+type suppressor interface {
+	Filter(recipients []string, category string) (allowed, suppressed []string)
+	Add(ctx context.Context, entry suppression.Entry) error
+}
+
+type unsubscriber interface {
+	Enabled() bool
+	URL(recipient, category string) string
+}
+
+type templateStore interface {
+	Enabled() bool
+	Get(name string) (store.Template, error)
+	FS(name string, version uint) (fs.FS, error)
+	Actives() (map[string]uint, error)
+	Create(name string, files map[string]string) (store.Version, error)
+	Activate(name string, version uint) error
+	RemoveVersion(name string, version uint) error
+	Remove(name string) error
+}
+
 const (
 	templateExtension = ".tmpl"
 	jsonExtension     = ".json"
 )
 
-var bufferPool = sync.Pool{
-	New: func() any {
-		return bytes.NewBuffer(nil)
-	},
-}
-
 type Service struct {
-	senderService sender
-	tpl           *template.Template
-	templatesDir  string
-	tracer        trace.Tracer
-	mjmlService   mjml.Service
+	headersAllowlist   map[string]struct{}
+	senderService      sender
+	suppressionService suppressor
+	unsubscribeService unsubscriber
+	storeService       templateStore
+	imageFetcher       imageFetcher
+	templates          *atomic.Pointer[templates]
+	funcs              template.FuncMap
+	templatesFS        fs.FS
+	templatesArchive   string
+	templatesSource    string
+	defaultLocale      string
+	tracer             trace.Tracer
+	mjmlService        mjml.Service
+	reloadInterval     time.Duration
+	clippingSize       int
+	strict             bool
+	mjmlPrecompile     bool
+	inlineCSS          bool
+	minify             bool
+	clippingFail       bool
+	embedImages        bool
 }
 
 type Config struct {
-	TemplatesDir string
+	// TemplatesFS takes precedence over TemplatesDir, e.g. for templates embedded in the binary
+	TemplatesFS      fs.FS
+	TemplatesDir     string
+	DefaultLocale    string
+	HeadersAllowlist []string
+	ReloadInterval   time.Duration
+	ClippingSize     int
+	ValidateOnly     bool
+	Strict           bool
+	MjmlPrecompile   bool
+	InlineCSS        bool
+	Minify           bool
+	ClippingFail     bool
+	EmbedImages      bool
 }
 
 func Flags(fs *flag.FlagSet, prefix string) *Config {
 	var config Config
 
-	flags.New("Templates", "Templates directory").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.TemplatesDir, "./templates/", nil)
+	flags.New("Templates", "Templates directory, or .zip/.tar.gz archive").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.TemplatesDir, "./templates/", nil)
+	flags.New("TemplatesReloadInterval", "Interval for checking templates changes, 0 to disable").Prefix(prefix).DocPrefix("mailer").DurationVar(fs, &config.ReloadInterval, 0, nil)
+	flags.New("DefaultLocale", "Locale of translations used when mail request's one is missing").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.DefaultLocale, "en", nil)
+	flags.New("TemplatesStrict", "Fail rendering when payload lacks a key used by template (missingkey=error), overridable per template").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.Strict, false, nil)
+	flags.New("MjmlPrecompile", "Convert MJML templates to HTML once at load time, keeping template actions, overridable per template").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.MjmlPrecompile, false, nil)
+	flags.New("InlineCss", "Move CSS rules of HTML (non-MJML) templates in style attributes, overridable per template").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.InlineCSS, false, nil)
+	flags.New("Minify", "Minify rendered HTML, removing comments and collapsing whitespace, overridable per template").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.Minify, false, nil)
+	flags.New("ClippingSize", "Size in bytes of the encoded HTML part above which Gmail clips the mail, 0 to disable").Prefix(prefix).DocPrefix("mailer").IntVar(fs, &config.ClippingSize, 102*1024, nil)
+	flags.New("ClippingFail", "Fail rendering above clipping size instead of warning").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.ClippingFail, false, nil)
+	flags.New("EmbedImages", "Embed images of the templates directory, or fetched from their URL, as inline parts referenced by cid:, overridable per template").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.EmbedImages, false, nil)
+	flags.New("ValidateOnly", "Validate templates against their fixtures, print report and exit").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.ValidateOnly, false, nil)
+	flags.New("HeadersAllowlist", "Custom headers allowed in mail request").Prefix(prefix).DocPrefix("mailer").StringSliceVar(fs, &config.HeadersAllowlist, []string{"Auto-Submitted", "Precedence", "X-Auto-Response-Suppress", "X-Campaign", "X-Entity-Ref-ID", "X-Priority"}, nil)
 
 	return &config
 }
 
-func New(config *Config, mjmlService mjml.Service, senderService sender, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) Service {
-	slog.LogAttrs(context.Background(), slog.LevelInfo, "Loading templates...", slog.String("dir", config.TemplatesDir), slog.String("extension", templateExtension))
-	appTemplates, err := getTemplates(config.TemplatesDir, templateExtension)
-	if err != nil {
-		slog.LogAttrs(context.Background(), slog.LevelError, "get templates", slog.Any("error", err))
-	}
-
+func New(config *Config, mjmlService mjml.Service, senderService sender, suppressionService suppressor, unsubscribeService unsubscriber, storeService templateStore, imageFetcher imageFetcher, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (Service, error) {
 	mailer_metric.Create(meterProvider, "mailer.render")
+	mailer_metric.Create(meterProvider, "mailer.variant")
 
 	service := Service{
-		templatesDir: config.TemplatesDir,
-		tpl: template.Must(template.New("mailer").Funcs(template.FuncMap{
-			"odd": func(i int) bool {
-				return i%2 == 0
-			},
-			"split": func(s, separator string) []string {
-				return strings.Split(s, separator)
-			},
-			"contains": func(s, substr string) bool {
-				return strings.Contains(s, substr)
-			},
-		}).ParseFiles(appTemplates...)),
-
-		mjmlService:   mjmlService,
-		senderService: senderService,
+		templatesSource: config.TemplatesDir,
+		reloadInterval:  config.ReloadInterval,
+		strict:          config.Strict,
+		mjmlPrecompile:  config.MjmlPrecompile,
+		inlineCSS:       config.InlineCSS,
+		minify:          config.Minify,
+		clippingSize:    config.ClippingSize,
+		clippingFail:    config.ClippingFail,
+		embedImages:     config.EmbedImages,
+		defaultLocale:   config.DefaultLocale,
+		templates:       &atomic.Pointer[templates]{},
+		funcs:           funcs.New(),
+
+		headersAllowlist:   make(map[string]struct{}, len(config.HeadersAllowlist)),
+		mjmlService:        mjmlService,
+		senderService:      senderService,
+		suppressionService: suppressionService,
+		unsubscribeService: unsubscribeService,
+		storeService:       storeService,
+		imageFetcher:       imageFetcher,
+	}
+
+	service.funcs["unsubscribe"] = unsubscribeService.URL
+	service.funcs["locale"] = func() string {
+		return config.DefaultLocale
+	}
+	service.funcs["t"] = func(key string, _ ...any) string {
+		return key
+	}
+
+	switch {
+	case config.TemplatesFS != nil:
+		service.templatesFS = config.TemplatesFS
+		service.templatesSource = "fs"
+	case isArchive(config.TemplatesDir):
+		service.templatesArchive = config.TemplatesDir
+	default:
+		service.templatesFS = os.DirFS(config.TemplatesDir)
+	}
+
+	for _, header := range config.HeadersAllowlist {
+		service.headersAllowlist[strings.ToLower(strings.TrimSpace(header))] = struct{}{}
 	}
 
 	if tracerProvider != nil {
 		service.tracer = tracerProvider.Tracer("mailer")
 	}
 
-	return service
+	if err := service.Reload(context.Background()); err != nil {
+		return service, fmt.Errorf("load templates: %w", err)
+	}
+
+	return service, nil
 }
 
 func (s Service) Enabled() bool {
-	return s.tpl != nil
+	return len(s.sets()) != 0
 }
 
 func (s Service) AmqpHandler(ctx context.Context, message amqp.Delivery) (err error) {
@@ -108,76 +197,284 @@ func (s Service) AmqpHandler(ctx context.Context, message amqp.Delivery) (err er
 		return fmt.Errorf("parse payload: %w", err)
 	}
 
+	mailRequest = s.WithMetadata(ctx, mailRequest)
+
+	if err = mailRequest.Check(); err != nil {
+		// Retrying won't fix an invalid request, message is dropped
+		slog.LogAttrs(ctx, slog.LevelError, "invalid mail request", slog.String("template", mailRequest.Tpl), slog.Any("error", err))
+		return nil
+	}
+
 	output, err := s.Render(ctx, mailRequest)
 	if err != nil {
+		if errors.Is(err, httpModel.ErrInvalid) {
+			// Retrying won't fix an invalid request, message is dropped
+			slog.LogAttrs(ctx, slog.LevelError, "invalid mail request", slog.String("template", mailRequest.Tpl), slog.Any("error", err))
+			return nil
+		}
+
 		return fmt.Errorf("render email: %w", err)
 	}
 
-	return s.Send(ctx, mailRequest.ConvertToMail(ctx, output))
+	result, err := s.Send(ctx, output.Mail(ctx, mailRequest))
+	if errors.Is(err, httpModel.ErrInvalid) {
+		slog.LogAttrs(ctx, slog.LevelError, "invalid mail", slog.String("template", mailRequest.Tpl), slog.Any("error", err))
+		return nil
+	}
+
+	if err != nil && len(result.Sent) != 0 {
+		// Retrying would send the mail again to the recipients who already received it, message is dropped
+		slog.LogAttrs(ctx, slog.LevelError, "mail partially sent", slog.String("template", mailRequest.Tpl), slog.Any("failed", result.Failed), slog.Any("error", err))
+		return nil
+	}
+
+	return err
 }
 
-func (s Service) Render(ctx context.Context, mailRequest model.MailRequest) (io.Reader, error) {
+// Render executes the template, or one of its A/B variants chosen from the recipients, with the payload of the mail request
+func (s Service) Render(ctx context.Context, mailRequest model.MailRequest) (Output, error) {
+	var output Output
 	var err error
 
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "render")
 	defer end(&err)
 
-	tpl := s.tpl.Lookup(fmt.Sprintf("%s%s", mailRequest.Tpl, templateExtension))
-	if tpl == nil {
-		return nil, fmt.Errorf("template `%s`: %w", mailRequest.Tpl, httpModel.ErrNotFound)
+	state, err := s.state(ctx, mailRequest.Tpl, mailRequest.Version)
+	if err != nil {
+		return output, err
+	}
+
+	if state == nil {
+		return output, fmt.Errorf("template `%s`: %w", mailRequest.Tpl, httpModel.ErrNotFound)
 	}
 
-	buffer := bufferPool.Get().(*bytes.Buffer)
-	defer bufferPool.Put(buffer)
-	buffer.Reset()
+	name := mailRequest.Tpl
+	if variants := state.variants[name]; len(variants) != 0 {
+		name = chooseVariant(name, variants, state.metadata[name].Weights, mailRequest.Recipients)
+	}
+
+	set, ok := lookupSet(state, name, mailRequest.Locale)
+	if !ok {
+		return output, fmt.Errorf("template `%s`: %w", name, httpModel.ErrNotFound)
+	}
+
+	tpl := s.localize(set, mailRequest.Locale, s.isStrict(state, name))
+
+	if err = checkPayload(state.schemas[baseName(name)], mailRequest.Payload); err != nil {
+		mailer_metric.Increase(ctx, "render", "invalid")
+		return output, httpModel.WrapInvalid(err)
+	}
+
+	// Content is read after rendering, so the buffer can't come from a pool
+	buffer := bytes.NewBuffer(nil)
 
 	if err = tpl.Execute(buffer, mailRequest.Payload); err != nil {
 		mailer_metric.Increase(ctx, "render", "error")
-		return nil, fmt.Errorf("execute: %w", err)
+
+		if missingErr, ok := asMissingKey(err); ok {
+			return output, httpModel.WrapInvalid(missingErr)
+		}
+
+		return output, fmt.Errorf("execute: %w", err)
 	}
 
 	mailer_metric.Increase(ctx, "render", "success")
 
+	if base, variant := splitVariant(name); len(variant) != 0 {
+		output.Variant = name
+		mailer_metric.Increase(ctx, "variant", "rendered", variantAttributes(base, variant)...)
+	}
+
+	if s.isInlined(state, name, set) {
+		if err = inlineCSS(buffer); err != nil {
+			return output, err
+		}
+	}
+
 	if err = s.convertMjml(ctx, buffer); err != nil {
-		return nil, fmt.Errorf("convert mjml: %w", err)
+		return output, fmt.Errorf("convert mjml: %w", err)
+	}
+
+	if s.isMinified(state, name) {
+		if err = minifyHTML(buffer); err != nil {
+			return output, err
+		}
+	}
+
+	if s.isEmbeddingImages(state, name) {
+		if output.Inlines, err = s.embed(ctx, state.fsys, buffer); err != nil {
+			return output, fmt.Errorf("embed images: %w", err)
+		}
+	}
+
+	output.Size = smtp.EncodedSize(buffer.Bytes())
+
+	if output.Clipped, err = s.checkClipping(ctx, name, output.Size); err != nil {
+		return output, err
 	}
 
-	return buffer, nil
+	output.Reader = buffer
+
+	return output, nil
 }
 
-func (s Service) Send(ctx context.Context, mail model.Mail) (err error) {
+func (s Service) Send(ctx context.Context, mail model.Mail) (result model.Result, err error) {
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "send")
 	defer end(&err)
 
-	return s.senderService.Send(ctx, mail)
-}
+	if err = s.checkHeaders(mail.Headers); err != nil {
+		return result, httpModel.WrapInvalid(err)
+	}
+
+	if err = model.CheckMessageIDs(append([]string{mail.MessageID, mail.InReplyTo}, mail.References...)...); err != nil {
+		return result, httpModel.WrapInvalid(err)
+	}
+
+	if err = mail.DSN.Check(); err != nil {
+		return result, httpModel.WrapInvalid(err)
+	}
+
+	mail.To, result.Suppressed = s.suppressionService.Filter(mail.To, mail.Category)
+	if len(result.Suppressed) != 0 {
+		slog.LogAttrs(ctx, slog.LevelInfo, "skipping suppressed recipients", slog.String("category", mail.Category), slog.Any("recipients", result.Suppressed))
+	}
+
+	if len(mail.To) == 0 {
+		return result, nil
+	}
+
+	if len(mail.MessageID) == 0 {
+		mail.MessageID = model.NewMessageID(mail.From)
+	}
+
+	result.MessageID = mail.MessageID
+	result.Variant = mail.Variant
 
-func (s Service) ListTemplates() []string {
-	var templatesList []string
+	if len(mail.Category) == 0 || !s.unsubscribeService.Enabled() {
+		err = s.send(ctx, mail)
+		result.Sent, result.Failed = partition(mail.To, err)
 
-	for _, tpl := range s.tpl.Templates() {
-		if before, ok := strings.CutSuffix(tpl.Name(), templateExtension); ok {
-			templatesList = append(templatesList, before)
+		return result, err
+	}
+
+	// One-click unsubscribe header is signed per recipient, so each one receives its own message
+	content, err := io.ReadAll(mail.Content)
+	if err != nil {
+		return result, fmt.Errorf("read content: %w", err)
+	}
+
+	for _, recipient := range mail.To {
+		recipientMail := mail
+		recipientMail.To = []string{recipient}
+		recipientMail.Content = bytes.NewReader(content)
+		recipientMail.Unsubscribe = s.unsubscribeService.URL(recipient, mail.Category)
+
+		if sendErr := s.send(ctx, recipientMail); sendErr != nil {
+			err = errors.Join(err, sendErr)
+			result.Failed = append(result.Failed, recipient)
+
+			continue
 		}
+
+		result.Sent = append(result.Sent, recipient)
 	}
 
-	return templatesList
+	return result, err
 }
 
-func getTemplates(dir, extension string) ([]string, error) {
-	var templates []string
+func (s Service) send(ctx context.Context, mail model.Mail) error {
+	err := s.senderService.Send(ctx, mail)
+	if err != nil {
+		s.suppressRejected(ctx, err)
+	}
 
-	return templates, filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+	if base, variant := splitVariant(mail.Variant); len(variant) != 0 {
+		state := "sent"
 		if err != nil {
+			state = "error"
+		}
+
+		mailer_metric.Increase(ctx, "variant", state, variantAttributes(base, variant)...)
+	}
+
+	return err
+}
+
+func (s Service) checkHeaders(headers map[string]string) error {
+	for key, value := range headers {
+		if err := model.CheckHeader(key, value); err != nil {
 			return err
 		}
 
-		if path.Ext(filename) == extension {
-			templates = append(templates, filename)
+		if _, ok := s.headersAllowlist[strings.ToLower(key)]; !ok {
+			return fmt.Errorf("header `%s` is not allowed", key)
 		}
+	}
 
+	return nil
+}
+
+// partition splits recipients between sent and failed ones, every recipient failing if the error isn't specific to some of them
+func partition(recipients []string, err error) (sent, failed []string) {
+	if err == nil {
+		return recipients, nil
+	}
+
+	recipientErrs, ok := model.RecipientErrors(err)
+	if !ok {
+		return nil, recipients
+	}
+
+	for _, recipient := range recipients {
+		if slices.ContainsFunc(recipientErrs, func(recipientErr model.RecipientError) bool {
+			return recipientErr.Recipient == recipient
+		}) {
+			failed = append(failed, recipient)
+		} else {
+			sent = append(sent, recipient)
+		}
+	}
+
+	return sent, failed
+}
+
+func (s Service) suppressRejected(ctx context.Context, err error) {
+	recipientErrs, _ := model.RecipientErrors(err)
+
+	for _, recipientErr := range recipientErrs {
+		if !recipientErr.Permanent() {
+			continue
+		}
+
+		if suppressErr := s.suppressionService.Add(ctx, suppression.Entry{
+			Email:  recipientErr.Recipient,
+			Reason: suppression.ReasonBounce,
+			Detail: recipientErr.Err.Error(),
+		}); suppressErr != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "suppress rejected recipient", slog.String("recipient", recipientErr.Recipient), slog.Any("error", suppressErr))
+		}
+	}
+}
+
+// ListTemplates lists sendable templates, without their localized and A/B variants
+func (s Service) ListTemplates() []Template {
+	current := s.templates.Load()
+	if current == nil {
 		return nil
-	})
+	}
+
+	var templatesList []Template
+
+	for _, name := range slices.Sorted(maps.Keys(current.metadata)) {
+		templatesList = append(templatesList, Template{
+			Name:     name,
+			Metadata: current.metadata[name],
+			Locales:  current.locales[name],
+			Variants: current.variants[name],
+		})
+	}
+
+	return templatesList
 }
 
 func (s Service) convertMjml(ctx context.Context, content *bytes.Buffer) error {
@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/mjml"
+	"github.com/ViBiOh/mailer/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/store"
+	"github.com/ViBiOh/mailer/pkg/unsubscribe"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type countingSender struct {
+	calls *int
+}
+
+func (cs countingSender) Send(context.Context, model.Mail) error {
+	*cs.calls++
+
+	return nil
+}
+
+type testDependencies struct {
+	mjmlService   mjml.Service
+	senderService sender
+	storeService  templateStore
+	imageFetcher  imageFetcher
+}
+
+// newTestService creates the service with given config, and without any dependency unless overridden
+func newTestService(t *testing.T, config Config, overrides ...func(*testDependencies)) Service {
+	t.Helper()
+
+	dependencies := testDependencies{
+		storeService: store.Service{},
+	}
+
+	for _, override := range overrides {
+		override(&dependencies)
+	}
+
+	instance, err := New(&config, dependencies.mjmlService, dependencies.senderService, nil, unsubscribe.New(&unsubscribe.Config{}), dependencies.storeService, dependencies.imageFetcher, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return instance
+}
+
+func TestAmqpHandlerInvalid(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"}, func(dependencies *testDependencies) { dependencies.senderService = countingSender{calls: &calls} })
+
+	body := []byte(`{"tpl":"hello","recipients":["bob@localhost"],"messageID":"<id@localhost>\r\nBcc: eve@localhost","payload":{"Name":"Bob"}}`)
+
+	if err := instance.AmqpHandler(context.Background(), amqp.Delivery{Body: body}); err != nil {
+		t.Errorf("AmqpHandler() = %v, want nil", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("AmqpHandler() sent %d mails, want none", calls)
+	}
+}
+
+func TestSendInvalid(t *testing.T) {
+	t.Parallel()
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"})
+
+	cases := map[string]struct {
+		mail model.Mail
+	}{
+		"message id": {
+			model.Mail{MessageID: "<id@localhost>\r\nBcc: eve@localhost"},
+		},
+		"in reply to": {
+			model.Mail{InReplyTo: "<id@localhost>\nBcc: eve@localhost"},
+		},
+		"references": {
+			model.Mail{References: []string{"<id@localhost>\r\n\r\n<h1>Injected</h1>"}},
+		},
+		"dsn": {
+			model.Mail{DSN: model.DSN{Notify: []string{"SUCCESS\r\nRCPT TO:<eve@localhost>"}}},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			testCase.mail.To = []string{"bob@localhost"}
+
+			if _, gotErr := instance.Send(context.Background(), testCase.mail); !errors.Is(gotErr, httpModel.ErrInvalid) {
+				t.Errorf("Send() = %v, want %v", gotErr, httpModel.ErrInvalid)
+			}
+		})
+	}
+}
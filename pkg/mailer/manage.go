@@ -0,0 +1,151 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/store"
+)
+
+var (
+	ErrStoreDisabled = errors.New("templates store is disabled")
+	ErrInvalidFiles  = errors.New("template is invalid")
+)
+
+// reservedNames can't be used by managed templates, because of their special meaning in templates directory or in API
+var reservedNames = []string{layoutsDirname, partialsDirname, "reload", "validate"}
+
+// state gives the templates to render with: the current ones, or the ones including a pinned version of the template, loaded once
+func (s Service) state(ctx context.Context, templateName string, version uint) (*templates, error) {
+	current := s.templates.Load()
+	if current == nil || current.fsys == nil || version == 0 || current.versions[templateName] == version {
+		return current, nil
+	}
+
+	if !s.storeService.Enabled() {
+		return nil, fmt.Errorf("version %d of `%s`: %w", version, templateName, httpModel.ErrNotFound)
+	}
+
+	key := fmt.Sprintf("%s/%d", templateName, version)
+	if pinned, ok := current.pinned.Load(key); ok {
+		return pinned.(*templates), nil
+	}
+
+	versionFS, err := s.storeService.FS(templateName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned, err := s.load(overlayFS{base: current.fsys, overrides: map[string]fs.FS{templateName: versionFS}})
+	if err != nil {
+		return nil, fmt.Errorf("load version %d of `%s`: %w", version, templateName, err)
+	}
+
+	s.precompileSets(ctx, pinned)
+
+	current.pinned.Store(key, pinned)
+
+	return pinned, nil
+}
+
+// Publish stores the files as a new version of the template, activated only when it's valid against its fixtures
+func (s Service) Publish(ctx context.Context, templateName string, files map[string]string) (store.Version, ValidationReport, error) {
+	var report ValidationReport
+
+	if !s.storeService.Enabled() {
+		return store.Version{}, report, httpModel.WrapInvalid(ErrStoreDisabled)
+	}
+
+	if err := checkManagedName(templateName); err != nil {
+		return store.Version{}, report, err
+	}
+
+	version, err := s.storeService.Create(templateName, files)
+	if err != nil {
+		return version, report, fmt.Errorf("create: %w", err)
+	}
+
+	versionFS, err := s.storeService.FS(templateName, version.Number)
+	if err != nil {
+		return version, report, fmt.Errorf("open version: %w", err)
+	}
+
+	base, _, err := s.openFS()
+	if err != nil {
+		return version, report, fmt.Errorf("open: %w", err)
+	}
+
+	report = s.validate(overlayFS{base: base, overrides: map[string]fs.FS{templateName: versionFS}}, templateName)
+	if !report.Valid {
+		if removeErr := s.storeService.RemoveVersion(templateName, version.Number); removeErr != nil {
+			return version, report, fmt.Errorf("remove invalid version: %w", removeErr)
+		}
+
+		return version, report, httpModel.WrapInvalid(ErrInvalidFiles)
+	}
+
+	if err = s.storeService.Activate(templateName, version.Number); err != nil {
+		return version, report, fmt.Errorf("activate: %w", err)
+	}
+
+	return version, report, s.Reload(ctx)
+}
+
+// Rollback activates the given version of the template, or the one before the active version if zero
+func (s Service) Rollback(ctx context.Context, templateName string, version uint) error {
+	if !s.storeService.Enabled() {
+		return httpModel.WrapInvalid(ErrStoreDisabled)
+	}
+
+	if version == 0 {
+		stored, err := s.storeService.Get(templateName)
+		if err != nil {
+			return err
+		}
+
+		previous, ok := stored.Previous()
+		if !ok {
+			return httpModel.WrapInvalid(fmt.Errorf("no version of `%s` before %d", templateName, stored.Active))
+		}
+
+		version = previous
+	}
+
+	if err := s.storeService.Activate(templateName, version); err != nil {
+		return fmt.Errorf("activate: %w", err)
+	}
+
+	return s.Reload(ctx)
+}
+
+// Unpublish removes the given version of the template, or the template with all its versions if zero
+func (s Service) Unpublish(ctx context.Context, templateName string, version uint) error {
+	if !s.storeService.Enabled() {
+		return httpModel.WrapInvalid(ErrStoreDisabled)
+	}
+
+	var err error
+	if version == 0 {
+		err = s.storeService.Remove(templateName)
+	} else {
+		err = s.storeService.RemoveVersion(templateName, version)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.Reload(ctx)
+}
+
+func checkManagedName(templateName string) error {
+	if slices.Contains(reservedNames, templateName) {
+		return httpModel.WrapInvalid(fmt.Errorf("template name `%s` is reserved", templateName))
+	}
+
+	return store.CheckName(templateName)
+}
@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/store"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(dir, "hello"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hello", "hello.tmpl"), []byte("Hello {{ .Name }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	storeService, err := store.New(&store.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir}, func(dependencies *testDependencies) { dependencies.storeService = storeService })
+
+	if _, _, err := instance.Publish(context.Background(), "partials", map[string]string{"partials.tmpl": "Hi"}); !errors.Is(err, httpModel.ErrInvalid) {
+		t.Errorf("Publish() = %v, want reserved name", err)
+	}
+
+	if _, report, err := instance.Publish(context.Background(), "hello", map[string]string{
+		"hello.tmpl":   "Hi {{ .Name.First }}",
+		"default.json": `{"Name": "Bob"}`,
+	}); !errors.Is(err, ErrInvalidFiles) || report.Valid {
+		t.Errorf("Publish() = (%+v, %v), want invalid fixture", report, err)
+	}
+
+	for _, content := range []string{"Hi {{ .Name }}", "Bye {{ .Name }}"} {
+		if _, _, err := instance.Publish(context.Background(), "hello", map[string]string{"hello.tmpl": content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	render := func(version uint) string {
+		output, err := instance.Render(context.Background(), model.NewMailRequest().Template("hello").WithVersion(version).Data(map[string]any{"Name": "Bob"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := io.ReadAll(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(content)
+	}
+
+	if got := render(0); got != "Bye Bob" {
+		t.Errorf("Render() = `%s`, want active version", got)
+	}
+
+	if got := render(1); got != "Hi Bob" {
+		t.Errorf("Render() = `%s`, want pinned version", got)
+	}
+
+	if err := instance.Rollback(context.Background(), "hello", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := render(0); got != "Hi Bob" {
+		t.Errorf("Render() = `%s`, want previous version", got)
+	}
+
+	if err := instance.Unpublish(context.Background(), "hello", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := render(0); got != "Hello Bob" {
+		t.Errorf("Render() = `%s`, want templates directory's one", got)
+	}
+}
@@ -0,0 +1,89 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+const metadataFilename = "meta"
+
+// Metadata describes default values of a template, applied when the mail request leaves them empty
+type Metadata struct {
+	Weights     map[string]uint `json:"weights,omitempty"`
+	Subject     string          `json:"subject,omitempty"`
+	From        string          `json:"from,omitempty"`
+	Sender      string          `json:"sender,omitempty"`
+	Category    string          `json:"category,omitempty"`
+	Layout      string          `json:"layout,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+	Precompile  *bool           `json:"precompile,omitempty"`
+	InlineCSS   *bool           `json:"inlineCss,omitempty"`
+	Minify      *bool           `json:"minify,omitempty"`
+	EmbedImages *bool           `json:"embedImages,omitempty"`
+}
+
+type Template struct {
+	Metadata
+	Name     string   `json:"name"`
+	Locales  []string `json:"locales,omitempty"`
+	Variants []string `json:"variants,omitempty"`
+}
+
+func loadMetadata(fsys fs.FS, templateName string) (Metadata, error) {
+	var metadata Metadata
+
+	content, err := fs.ReadFile(fsys, path.Join(templateName, metadataFilename+jsonExtension))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return metadata, nil
+		}
+
+		return metadata, fmt.Errorf("read metadata of `%s`: %w", templateName, err)
+	}
+
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return metadata, fmt.Errorf("parse metadata of `%s`: %w", templateName, err)
+	}
+
+	return metadata, nil
+}
+
+func (s Service) Metadata(templateName string) Metadata {
+	if current := s.templates.Load(); current != nil {
+		return current.metadata[templateName]
+	}
+
+	return Metadata{}
+}
+
+// WithMetadata fills empty fields of the mail request with metadata of the template, in its pinned version if any
+func (s Service) WithMetadata(ctx context.Context, mailRequest model.MailRequest) model.MailRequest {
+	var metadata Metadata
+	if state, err := s.state(ctx, mailRequest.Tpl, mailRequest.Version); err == nil && state != nil {
+		metadata = state.metadata[baseName(mailRequest.Tpl)]
+	}
+
+	if len(mailRequest.Subject) == 0 {
+		mailRequest = mailRequest.WithSubject(metadata.Subject)
+	}
+
+	if len(mailRequest.FromEmail) == 0 {
+		mailRequest = mailRequest.From(metadata.From)
+	}
+
+	if len(mailRequest.Sender) == 0 {
+		mailRequest = mailRequest.As(metadata.Sender)
+	}
+
+	if len(mailRequest.Category) == 0 {
+		mailRequest = mailRequest.WithCategory(metadata.Category)
+	}
+
+	return mailRequest
+}
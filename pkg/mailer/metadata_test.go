@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"})
+
+	cases := map[string]struct {
+		mailRequest model.MailRequest
+		want        model.MailRequest
+	}{
+		"no metadata": {
+			model.NewMailRequest().Template("ketchup"),
+			model.NewMailRequest().Template("ketchup"),
+		},
+		"defaults": {
+			model.NewMailRequest().Template("hello"),
+			model.NewMailRequest().Template("hello").WithSubject("Hello {{ .Name }}").From("mailer@vibioh.fr").As("Mailer"),
+		},
+		"override": {
+			model.NewMailRequest().Template("hello").WithSubject("Hi").From("bob@localhost"),
+			model.NewMailRequest().Template("hello").WithSubject("Hi").From("bob@localhost").As("Mailer"),
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := instance.WithMetadata(context.Background(), testCase.mailRequest); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("WithMetadata() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
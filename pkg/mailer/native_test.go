@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/mjml"
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestRenderNative renders the default fixture of every template of the repository with the built-in MJML converter
+func TestRenderNative(t *testing.T) {
+	t.Parallel()
+
+	mjmlService, err := mjml.New(&mjml.Config{Backend: mjml.BackendNative}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"}, func(dependencies *testDependencies) { dependencies.mjmlService = mjmlService })
+
+	for _, template := range instance.ListTemplates() {
+		t.Run(template.Name, func(t *testing.T) {
+			t.Parallel()
+
+			fixture, err := instance.GetFixture(template.Name, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template(template.Name).Data(fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := io.ReadAll(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", template.Name+".html")
+
+			if *update {
+				if err = os.WriteFile(golden, actual, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(actual) != string(expected) {
+				t.Errorf("Render() differs from `%s`, run with -update for reviewing the diff:\n%s", golden, actual)
+			}
+		})
+	}
+}
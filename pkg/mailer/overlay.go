@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// overlayFS replaces template directories of the base filesystem, e.g. by stored versions
+type overlayFS struct {
+	base      fs.FS
+	overrides map[string]fs.FS
+}
+
+func (o overlayFS) route(name string) (fs.FS, string) {
+	dir, rest, _ := strings.Cut(name, "/")
+
+	override, ok := o.overrides[dir]
+	if !ok {
+		return o.base, name
+	}
+
+	if len(rest) == 0 {
+		rest = "."
+	}
+
+	return override, rest
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	fsys, name := o.route(name)
+
+	return fsys.Open(name)
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		fsys, name := o.route(name)
+
+		return fs.ReadDir(fsys, name)
+	}
+
+	entries, err := fs.ReadDir(o.base, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	entries = slices.DeleteFunc(entries, func(entry fs.DirEntry) bool {
+		_, ok := o.overrides[entry.Name()]
+		return ok
+	})
+
+	for name := range o.overrides {
+		entries = append(entries, overrideEntry{name: name})
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// overrideEntry is the directory of an override, at the root of the overlay
+type overrideEntry struct {
+	name string
+}
+
+func (oe overrideEntry) Name() string               { return oe.name }
+func (oe overrideEntry) IsDir() bool                { return true }
+func (oe overrideEntry) Type() fs.FileMode          { return fs.ModeDir }
+func (oe overrideEntry) Info() (fs.FileInfo, error) { return oe, nil }
+func (oe overrideEntry) Size() int64                { return 0 }
+func (oe overrideEntry) Mode() fs.FileMode          { return fs.ModeDir | 0o500 }
+func (oe overrideEntry) ModTime() time.Time         { return time.Time{} }
+func (oe overrideEntry) Sys() any                   { return nil }
@@ -0,0 +1,312 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ViBiOh/mailer/pkg/mjml"
+)
+
+const (
+	maxInlineDepth    = 16
+	placeholderFormat = "MJTPL%dX"
+)
+
+var (
+	errNotMJML      = errors.New("template is not MJML")
+	errNotInlinable = errors.New("template can't be inlined")
+
+	mjmlTagPattern = regexp.MustCompile(`<(/?)(mj[a-z-]*)\b[^>]*?(/?)>`)
+
+	// mjmlEndingTags are components containing raw HTML instead of other components
+	mjmlEndingTags = map[string]bool{"mj-text": true, "mj-button": true, "mj-table": true, "mj-raw": true, "mj-style": true, "mj-title": true, "mj-preview": true, "mj-navbar-link": true, "mj-accordion-title": true, "mj-accordion-text": true, "mj-social-element": true}
+)
+
+// protectedSource is the MJML source of a template, flattened with every called template, its actions being replaced by placeholders
+type protectedSource struct {
+	source   strings.Builder
+	actions  []string
+	controls []bool
+}
+
+// precompileSets converts MJML templates to HTML once, for not calling the MJML converter on each render. A template that can't be precompiled is converted on each render.
+func (s Service) precompileSets(ctx context.Context, loaded *templates) {
+	if !s.mjmlService.Enabled() {
+		return
+	}
+
+	for name, set := range loaded.sets {
+		if !s.isPrecompiled(loaded, name) {
+			continue
+		}
+
+		tpl, err := s.precompile(ctx, set.tpl)
+		if err != nil {
+			if !errors.Is(err, errNotMJML) {
+				slog.LogAttrs(ctx, slog.LevelWarn, "precompile template, MJML will be converted on each render", slog.String("template", name), slog.Any("error", err))
+			}
+
+			continue
+		}
+
+		strict, err := newStrictTemplate(tpl)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "precompile strict template", slog.String("template", name), slog.Any("error", err))
+			continue
+		}
+
+		precompiled, err := s.localizeSet(loaded, name, templateSet{
+			tpl:         tpl,
+			strict:      strict,
+			precompiled: true,
+		})
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "localize precompiled template", slog.String("template", name), slog.Any("error", err))
+			continue
+		}
+
+		loaded.sets[name] = precompiled
+	}
+}
+
+// isPrecompiled checks if template is converted at load time, template's metadata overriding global configuration
+func (s Service) isPrecompiled(state *templates, templateName string) bool {
+	if precompile := state.metadata[baseName(templateName)].Precompile; precompile != nil {
+		return *precompile
+	}
+
+	return s.mjmlPrecompile
+}
+
+func (s Service) precompile(ctx context.Context, tpl *template.Template) (*template.Template, error) {
+	if tpl.Tree == nil {
+		return nil, errNotMJML
+	}
+
+	var protected protectedSource
+	if err := protected.walk(tpl, tpl.Tree.Root, 0); err != nil {
+		return nil, err
+	}
+
+	source := protected.source.String()
+	if !mjml.IsMJML([]byte(source)) {
+		return nil, errNotMJML
+	}
+
+	// Source is the template without its data, it can be kept on disk
+	output, err := s.mjmlService.Render(mjml.WithPersistence(ctx), source)
+	if err != nil {
+		return nil, fmt.Errorf("convert: %w", err)
+	}
+
+	restored, err := protected.restore(output)
+	if err != nil {
+		return nil, err
+	}
+
+	precompiled, err := template.New(tpl.Name()).Funcs(s.funcs).Parse(restored)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	return precompiled, nil
+}
+
+// walk writes the source of the node, inlining called templates in a single-item range for having the same dot
+func (p *protectedSource) walk(tpl *template.Template, node parse.Node, depth int) error {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return nil
+		}
+
+		for _, child := range typed.Nodes {
+			if err := p.walk(tpl, child, depth); err != nil {
+				return err
+			}
+		}
+
+	case *parse.TextNode:
+		p.source.Write(typed.Text)
+
+	case *parse.CommentNode:
+
+	case *parse.ActionNode:
+		p.protect(typed.String(), false)
+
+	case *parse.BreakNode, *parse.ContinueNode:
+		p.protect(typed.String(), true)
+
+	case *parse.IfNode:
+		return p.branch(tpl, "if", &typed.BranchNode, depth)
+
+	case *parse.RangeNode:
+		return p.branch(tpl, "range", &typed.BranchNode, depth)
+
+	case *parse.WithNode:
+		return p.branch(tpl, "with", &typed.BranchNode, depth)
+
+	case *parse.TemplateNode:
+		called := tpl.Lookup(typed.Name)
+		if depth >= maxInlineDepth || called == nil || called.Tree == nil || usesRoot(called.Tree.Root) {
+			return fmt.Errorf("`%s`: %w", typed.Name, errNotInlinable)
+		}
+
+		// Template called without pipeline has a nil dot
+		items := "list nil"
+		if typed.Pipe != nil {
+			items = fmt.Sprintf("list (%s)", typed.Pipe)
+		}
+
+		p.protect("{{range "+items+"}}", true)
+
+		if err := p.walk(tpl, called.Tree.Root, depth+1); err != nil {
+			return err
+		}
+
+		p.protect("{{end}}", true)
+
+	default:
+		return fmt.Errorf("node `%s`: %w", node, errNotInlinable)
+	}
+
+	return nil
+}
+
+func (p *protectedSource) branch(tpl *template.Template, keyword string, node *parse.BranchNode, depth int) error {
+	p.protect(fmt.Sprintf("{{%s %s}}", keyword, node.Pipe), true)
+
+	if err := p.walk(tpl, node.List, depth); err != nil {
+		return err
+	}
+
+	if node.ElseList != nil {
+		p.protect("{{else}}", true)
+
+		if err := p.walk(tpl, node.ElseList, depth); err != nil {
+			return err
+		}
+	}
+
+	p.protect("{{end}}", true)
+
+	return nil
+}
+
+// protect writes a placeholder for the action, in a mj-raw when it's between MJML components for being kept by the converter
+func (p *protectedSource) protect(action string, control bool) {
+	placeholder := fmt.Sprintf(placeholderFormat, len(p.actions))
+
+	p.actions = append(p.actions, action)
+	p.controls = append(p.controls, control)
+
+	if isMjmlContent(p.source.String()) {
+		p.source.WriteString(placeholder)
+	} else {
+		p.source.WriteString("<mj-raw>" + placeholder + "</mj-raw>")
+	}
+}
+
+// restore replaces placeholders by their action, control ones having to be found exactly once for keeping the structure of the template
+func (p *protectedSource) restore(output string) (string, error) {
+	replacements := make([]string, 0, len(p.actions)*2)
+
+	for index, action := range p.actions {
+		placeholder := fmt.Sprintf(placeholderFormat, index)
+
+		count := strings.Count(output, placeholder)
+		if count == 0 || (p.controls[index] && count != 1) {
+			return "", fmt.Errorf("action `%s` found %d times in converted output", action, count)
+		}
+
+		replacements = append(replacements, placeholder, action)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(output), nil
+}
+
+// isMjmlContent checks if the end of the source is in a tag, e.g. an attribute, or in a component containing raw HTML
+func isMjmlContent(source string) bool {
+	if strings.LastIndexByte(source, '<') > strings.LastIndexByte(source, '>') {
+		return true
+	}
+
+	var depth int
+
+	for _, match := range mjmlTagPattern.FindAllStringSubmatch(source, -1) {
+		if !mjmlEndingTags[match[2]] || len(match[3]) != 0 {
+			continue
+		}
+
+		if len(match[1]) == 0 {
+			depth++
+		} else {
+			depth--
+		}
+	}
+
+	return depth > 0
+}
+
+// usesRoot checks if nodes use the `$` variable, bound to the data given to the template, and so can't be inlined
+func usesRoot(node parse.Node) bool {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return false
+		}
+
+		for _, child := range typed.Nodes {
+			if usesRoot(child) {
+				return true
+			}
+		}
+
+	case *parse.ActionNode:
+		return usesRoot(typed.Pipe)
+
+	case *parse.IfNode:
+		return usesRoot(typed.Pipe) || usesRoot(typed.List) || usesRoot(typed.ElseList)
+
+	case *parse.RangeNode:
+		return usesRoot(typed.Pipe) || usesRoot(typed.List) || usesRoot(typed.ElseList)
+
+	case *parse.WithNode:
+		return usesRoot(typed.Pipe) || usesRoot(typed.List) || usesRoot(typed.ElseList)
+
+	case *parse.TemplateNode:
+		return usesRoot(typed.Pipe)
+
+	case *parse.PipeNode:
+		if typed == nil {
+			return false
+		}
+
+		for _, command := range typed.Cmds {
+			if usesRoot(command) {
+				return true
+			}
+		}
+
+	case *parse.CommandNode:
+		for _, arg := range typed.Args {
+			if usesRoot(arg) {
+				return true
+			}
+		}
+
+	case *parse.ChainNode:
+		return usesRoot(typed.Node)
+
+	case *parse.VariableNode:
+		return typed.Ident[0] == "$"
+	}
+
+	return false
+}
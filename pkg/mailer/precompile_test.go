@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/mjml"
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+var fakeComponent = regexp.MustCompile(`<(/?)mj-[a-z]+`)
+
+// fakeConverter converts components to div, keeping raw content as is, like the MJML API does
+func fakeConverter(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		html := strings.NewReplacer("<mjml>", "<html>", "</mjml>", "</html>", "<mj-raw>", "", "</mj-raw>", "").Replace(payload["mjml"])
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"html": fakeComponent.ReplaceAllString(html, "<${1}div")})
+	}))
+}
+
+func TestPrecompile(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := fakeConverter(t, &calls)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"partials/item.tmpl": `{{ define "item" }}<mj-text>{{ . }}</mj-text>{{ end }}`,
+		"layouts/base.tmpl":  `<mjml><mj-body>{{ block "content" . }}{{ end }}</mj-body></mjml>`,
+		"list/list.tmpl":     `{{ define "content" }}<mj-section title="{{ .Title }}">{{ range .Items }}{{ template "item" . }}{{ end }}</mj-section>{{ end }}`,
+		"list/meta.json":     `{"layout": "base"}`,
+		"root/root.tmpl":     `{{ define "content" }}{{ template "title" . }}{{ end }}`,
+		"root/title.tmpl":    `{{ define "title" }}<mj-text>{{ $.Title }}</mj-text>{{ end }}`,
+		"root/meta.json":     `{"layout": "base"}`,
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mjmlService, err := mjml.New(&mjml.Config{URL: server.URL}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir, MjmlPrecompile: true, InlineCSS: true}, func(dependencies *testDependencies) { dependencies.mjmlService = mjmlService })
+
+	if !instance.sets()["list"].precompiled || instance.sets()["root"].precompiled {
+		t.Error("precompile only templates that can be inlined")
+	}
+
+	loadCalls := calls.Load()
+
+	output, err := instance.Render(context.Background(), model.NewMailRequest().Template("list").Data(map[string]any{
+		"Title": "Releases",
+		"Items": []string{"first", "second"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `<html><div><div title="Releases"><div>first</div><div>second</div></div></div></html>`; strings.TrimSpace(string(got)) != want {
+		t.Errorf("Render() = `%s`, want `%s`", got, want)
+	}
+
+	if calls.Load() != loadCalls {
+		t.Errorf("Render() called converter %d times, want none", calls.Load()-loadCalls)
+	}
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/ViBiOh/mailer/pkg/schema"
+)
+
+const schemaFilename = "schema"
+
+func loadSchema(fsys fs.FS, templateName string) (*schema.Schema, error) {
+	content, err := fs.ReadFile(fsys, path.Join(templateName, schemaFilename+jsonExtension))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read schema of `%s`: %w", templateName, err)
+	}
+
+	output, err := schema.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("schema of `%s`: %w", templateName, err)
+	}
+
+	return output, nil
+}
+
+// checkPayload validates payload against the template's schema, if any
+func checkPayload(templateSchema *schema.Schema, payload any) error {
+	if templateSchema == nil {
+		return nil
+	}
+
+	if err := templateSchema.Validate(payload); err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestRenderSchema(t *testing.T) {
+	t.Parallel()
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"})
+
+	cases := map[string]struct {
+		payload any
+		wantErr error
+	}{
+		"valid": {
+			map[string]any{"Name": "World"},
+			nil,
+		},
+		"missing": {
+			map[string]any{},
+			httpModel.ErrInvalid,
+		},
+		"wrong type": {
+			map[string]any{"Name": 8000},
+			httpModel.ErrInvalid,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := instance.Render(context.Background(), model.NewMailRequest().Template("hello").Data(testCase.payload))
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("Render() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package mailer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+var archiveExtensions = []string{".zip", ".tar.gz", ".tgz"}
+
+func isArchive(filename string) bool {
+	for _, extension := range archiveExtensions {
+		if strings.HasSuffix(filename, extension) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// openFS gives the filesystem of templates, an archive being read again for seeing its changes, with the active versions of stored templates replacing the directories of the same name
+func (s Service) openFS() (fs.FS, map[string]uint, error) {
+	base := s.templatesFS
+
+	if len(s.templatesArchive) != 0 {
+		var err error
+		if base, err = openArchive(s.templatesArchive); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !s.storeService.Enabled() {
+		return base, nil, nil
+	}
+
+	actives, err := s.storeService.Actives()
+	if err != nil {
+		return nil, nil, fmt.Errorf("store: %w", err)
+	}
+
+	if len(actives) == 0 {
+		return base, actives, nil
+	}
+
+	overlay := overlayFS{
+		base:      base,
+		overrides: make(map[string]fs.FS, len(actives)),
+	}
+
+	for name, version := range actives {
+		if overlay.overrides[name], err = s.storeService.FS(name, version); err != nil {
+			return nil, nil, fmt.Errorf("store: %w", err)
+		}
+	}
+
+	return overlay, actives, nil
+}
+
+func openArchive(filename string) (fs.FS, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	if strings.HasSuffix(filename, ".zip") {
+		return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	}
+
+	return untar(content)
+}
+
+// untar converts a gzipped tarball to a zip, in memory, for using the filesystem of the latter
+func untar(content []byte) (fs.FS, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	var output bytes.Buffer
+	zipWriter := zip.NewWriter(&output)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("tar: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     strings.TrimPrefix(path.Clean(header.Name), "/"),
+			Modified: header.ModTime,
+			Method:   zip.Store,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create `%s`: %w", header.Name, err)
+		}
+
+		if _, err = io.Copy(writer, tarReader); err != nil {
+			return nil, fmt.Errorf("copy `%s`: %w", header.Name, err)
+		}
+	}
+
+	if err = zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+
+	return zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+}
+
+// getFingerprint hashes the name, size and modification time of the archive or of every file of the filesystem, and the active versions of stored templates
+func (s Service) getFingerprint() (uint64, error) {
+	hash := fnv.New64a()
+
+	if s.storeService.Enabled() {
+		actives, err := s.storeService.Actives()
+		if err != nil {
+			return 0, fmt.Errorf("store: %w", err)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(actives)) {
+			_, _ = fmt.Fprintf(hash, "%s@%d\n", name, actives[name])
+		}
+	}
+
+	if len(s.templatesArchive) != 0 {
+		info, err := os.Stat(s.templatesArchive)
+		if err != nil {
+			return 0, err
+		}
+
+		_, _ = fmt.Fprintf(hash, "%s|%d|%d\n", s.templatesArchive, info.Size(), info.ModTime().UnixNano())
+
+		return hash.Sum64(), nil
+	}
+
+	err := fs.WalkDir(s.templatesFS, ".", func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(hash, "%s|%d|%d\n", filename, info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	})
+
+	return hash.Sum64(), err
+}
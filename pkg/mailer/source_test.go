@@ -0,0 +1,140 @@
+package mailer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+var sourceFiles = map[string]string{
+	"partials/footer.tmpl": `{{ define "footer" }}!{{ end }}`,
+	"hello/hello.tmpl":     `Hello {{ .Name }}{{ template "footer" }}`,
+	"hello/default.json":   `{"Name": "World"}`,
+	"hello/meta.json":      `{"subject": "Hello"}`,
+}
+
+func writeZip(t *testing.T, filename string) {
+	t.Helper()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+
+	for name, content := range sourceFiles {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = io.WriteString(entry, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarball(t *testing.T, filename string) {
+	t.Helper()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	writer := tar.NewWriter(gzipWriter)
+
+	for name, content := range sourceFiles {
+		if err = writer.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = io.WriteString(writer, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeZip(t, filepath.Join(dir, "templates.zip"))
+	writeTarball(t, filepath.Join(dir, "templates.tar.gz"))
+
+	memory := make(fstest.MapFS)
+	for name, content := range sourceFiles {
+		memory[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	cases := map[string]struct {
+		config Config
+	}{
+		"fs": {
+			Config{TemplatesFS: memory},
+		},
+		"zip": {
+			Config{TemplatesDir: filepath.Join(dir, "templates.zip")},
+		},
+		"tarball": {
+			Config{TemplatesDir: filepath.Join(dir, "templates.tar.gz")},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			instance := newTestService(t, testCase.config)
+
+			if got := instance.Metadata("hello"); got.Subject != "Hello" {
+				t.Errorf("Metadata() = %+v, want subject", got)
+			}
+
+			fixtures, err := instance.ListFixtures("hello")
+			if err != nil || !slices.Equal(fixtures, []string{"default"}) {
+				t.Errorf("ListFixtures() = (%v, `%v`), want [default]", fixtures, err)
+			}
+
+			fixture, err := instance.GetFixture("hello", "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template("hello").Data(fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := io.ReadAll(output); string(got) != "Hello World!" {
+				t.Errorf("Render() = `%s`, want `Hello World!`", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+const strictOption = "missingkey=error"
+
+var missingKeyPattern = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?: .* map has no entry for key "([^"]*)"`)
+
+// MissingKeyError is returned in strict mode when the payload lacks a key used by the template
+type MissingKeyError struct {
+	Err      error
+	Template string
+	Key      string
+	Line     int
+}
+
+func (mke MissingKeyError) Error() string {
+	return fmt.Sprintf("template `%s`, line %d: missing key `%s`", mke.Template, mke.Line, mke.Key)
+}
+
+func (mke MissingKeyError) Unwrap() error {
+	return mke.Err
+}
+
+func newStrictTemplate(tpl *template.Template) (*template.Template, error) {
+	strict, err := tpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("clone: %w", err)
+	}
+
+	return strict.Option(strictOption), nil
+}
+
+// isStrict checks if template fails on missing keys, template's metadata overriding global configuration
+func (s Service) isStrict(state *templates, templateName string) bool {
+	if strict := state.metadata[baseName(templateName)].Strict; strict != nil {
+		return *strict
+	}
+
+	return s.strict
+}
+
+func (t *templates) lookup(templateName string, strict bool) *template.Template {
+	set, ok := t.sets[templateName]
+	if !ok {
+		return nil
+	}
+
+	if strict {
+		return set.strict
+	}
+
+	return set.tpl
+}
+
+// CheckStrict executes the template in strict mode, reporting the first key missing in payload
+func (s Service) CheckStrict(templateName string, payload any) error {
+	current := s.templates.Load()
+	if current == nil {
+		return nil
+	}
+
+	tpl := current.lookup(templateName, true)
+	if tpl == nil {
+		return nil
+	}
+
+	err := tpl.Execute(io.Discard, payload)
+	if missingErr, ok := asMissingKey(err); ok {
+		return missingErr
+	}
+
+	return err
+}
+
+func asMissingKey(err error) (MissingKeyError, bool) {
+	var execErr template.ExecError
+	if !errors.As(err, &execErr) {
+		return MissingKeyError{}, false
+	}
+
+	matches := missingKeyPattern.FindStringSubmatch(execErr.Error())
+	if len(matches) == 0 {
+		return MissingKeyError{}, false
+	}
+
+	line, _ := strconv.Atoi(matches[2])
+
+	return MissingKeyError{
+		Template: templateName(matches[1]),
+		Line:     line,
+		Key:      matches[3],
+		Err:      err,
+	}, true
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckStrict(t *testing.T) {
+	t.Parallel()
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"})
+
+	cases := map[string]struct {
+		payload any
+		want    MissingKeyError
+		wantErr bool
+	}{
+		"valid": {
+			map[string]any{"Name": "World"},
+			MissingKeyError{},
+			false,
+		},
+		"missing": {
+			map[string]any{"Nmae": "World"},
+			MissingKeyError{Template: "hello", Key: "Name"},
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := instance.CheckStrict("hello", testCase.payload)
+
+			if !testCase.wantErr {
+				if gotErr != nil {
+					t.Errorf("CheckStrict() = `%s`, want nil", gotErr)
+				}
+
+				return
+			}
+
+			var got MissingKeyError
+			if !errors.As(gotErr, &got) {
+				t.Fatalf("CheckStrict() = `%v`, want MissingKeyError", gotErr)
+			}
+
+			if got.Template != testCase.want.Template || got.Key != testCase.want.Key || got.Line == 0 {
+				t.Errorf("CheckStrict() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
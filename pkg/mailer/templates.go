@@ -0,0 +1,394 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ViBiOh/mailer/pkg/i18n"
+	"github.com/ViBiOh/mailer/pkg/schema"
+)
+
+const (
+	layoutsDirname  = "layouts"
+	partialsDirname = "partials"
+)
+
+var ErrNoTemplate = errors.New("no template loaded")
+
+type templates struct {
+	fsys        fs.FS
+	sets        map[string]templateSet
+	metadata    map[string]Metadata
+	schemas     map[string]*schema.Schema
+	catalogs    map[string]i18n.Catalogs
+	locales     map[string][]string
+	variants    map[string][]string
+	versions    map[string]uint
+	pinned      *sync.Map
+	err         error
+	loadedAt    time.Time
+	fingerprint uint64
+}
+
+// templateSet is a sendable template with its layout and partials, parsed apart from the others for having its own blocks
+type templateSet struct {
+	tpl         *template.Template
+	strict      *template.Template
+	localized   map[string]localizedSet
+	precompiled bool
+}
+
+// templateFiles lists the files composing templates
+type templateFiles struct {
+	main    map[string]string
+	layouts map[string]string
+	local   map[string][]string
+	shared  []string
+}
+
+// ReloadStatus describes the last templates reload
+type ReloadStatus struct {
+	LoadedAt time.Time `json:"loaded_at"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (s Service) filesystem() fs.FS {
+	if current := s.templates.Load(); current != nil {
+		return current.fsys
+	}
+
+	return nil
+}
+
+func (s Service) sets() map[string]templateSet {
+	if current := s.templates.Load(); current != nil {
+		return current.sets
+	}
+
+	return nil
+}
+
+func (s Service) ReloadStatus() ReloadStatus {
+	current := s.templates.Load()
+	if current == nil {
+		return ReloadStatus{Error: ErrNoTemplate.Error()}
+	}
+
+	output := ReloadStatus{
+		LoadedAt: current.loadedAt,
+	}
+
+	if current.err != nil {
+		output.Error = current.err.Error()
+	}
+
+	return output
+}
+
+// Reload parses templates into a new set, previous one is kept if parsing fails
+func (s Service) Reload(ctx context.Context) error {
+	fingerprint, err := s.getFingerprint()
+	if err != nil {
+		return s.reloadFailed(ctx, fingerprint, fmt.Errorf("fingerprint: %w", err))
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "Loading templates...", slog.String("source", s.templatesSource), slog.String("extension", templateExtension))
+
+	fsys, versions, err := s.openFS()
+	if err != nil {
+		return s.reloadFailed(ctx, fingerprint, fmt.Errorf("open: %w", err))
+	}
+
+	loaded, err := s.load(fsys)
+	if err != nil {
+		return s.reloadFailed(ctx, fingerprint, err)
+	}
+
+	s.precompileSets(ctx, loaded)
+
+	loaded.versions = versions
+	loaded.loadedAt = time.Now()
+	loaded.fingerprint = fingerprint
+
+	s.templates.Store(loaded)
+
+	return nil
+}
+
+func (s Service) load(fsys fs.FS) (*templates, error) {
+	files, err := getTemplateFiles(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("get templates: %w", err)
+	}
+
+	if len(files.main) == 0 {
+		return nil, ErrNoTemplate
+	}
+
+	loaded := templates{
+		fsys:     fsys,
+		metadata: make(map[string]Metadata),
+		schemas:  make(map[string]*schema.Schema),
+		catalogs: make(map[string]i18n.Catalogs),
+		locales:  make(map[string][]string),
+		variants: make(map[string][]string),
+		pinned:   &sync.Map{},
+	}
+
+	for name := range files.main {
+		name, locale := splitLocale(name)
+		name, variant := splitVariant(name)
+
+		if len(locale) != 0 {
+			loaded.locales[name] = append(loaded.locales[name], locale)
+		}
+
+		if len(variant) != 0 {
+			loaded.variants[name] = append(loaded.variants[name], variant)
+		}
+
+		if _, ok := loaded.metadata[name]; ok {
+			continue
+		}
+
+		if loaded.metadata[name], err = loadMetadata(fsys, name); err != nil {
+			return nil, err
+		}
+
+		if loaded.schemas[name], err = loadSchema(fsys, name); err != nil {
+			return nil, err
+		}
+
+		if loaded.catalogs[name], err = loadCatalogs(fsys, name); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, locales := range loaded.locales {
+		slices.Sort(locales)
+		loaded.locales[name] = slices.Compact(locales)
+	}
+
+	for name, variants := range loaded.variants {
+		slices.Sort(variants)
+		loaded.variants[name] = slices.Compact(variants)
+	}
+
+	if loaded.sets, err = s.parseTemplates(fsys, files, loaded.metadata); err != nil {
+		return nil, fmt.Errorf("parse templates: %w", err)
+	}
+
+	for name, set := range loaded.sets {
+		if loaded.sets[name], err = s.localizeSet(&loaded, name, set); err != nil {
+			return nil, fmt.Errorf("localize `%s`: %w", name, err)
+		}
+	}
+
+	return &loaded, nil
+}
+
+func (s Service) reloadFailed(ctx context.Context, fingerprint uint64, err error) error {
+	failed := templates{
+		err:         err,
+		loadedAt:    time.Now(),
+		fingerprint: fingerprint,
+	}
+
+	if previous := s.templates.Load(); previous != nil {
+		failed.fsys = previous.fsys
+		failed.sets = previous.sets
+		failed.metadata = previous.metadata
+		failed.schemas = previous.schemas
+		failed.catalogs = previous.catalogs
+		failed.locales = previous.locales
+		failed.variants = previous.variants
+		failed.versions = previous.versions
+		failed.pinned = previous.pinned
+		failed.loadedAt = previous.loadedAt
+	}
+
+	s.templates.Store(&failed)
+
+	slog.LogAttrs(ctx, slog.LevelError, "reload templates", slog.Any("error", err))
+
+	return err
+}
+
+// parseTemplates parses each template with the shared partials, its layout and its own partials, the latter overriding the shared ones
+func (s Service) parseTemplates(fsys fs.FS, files templateFiles, metadata map[string]Metadata) (map[string]templateSet, error) {
+	shared := template.New("mailer").Funcs(s.funcs)
+
+	if len(files.shared) != 0 {
+		if _, err := shared.ParseFS(fsys, files.shared...); err != nil {
+			return nil, fmt.Errorf("partials: %w", err)
+		}
+	}
+
+	sets := make(map[string]templateSet, len(files.main))
+
+	for name, file := range files.main {
+		base := baseName(name)
+
+		var layoutFile string
+		if layout := metadata[base].Layout; len(layout) != 0 {
+			var ok bool
+			if layoutFile, ok = files.layouts[layout]; !ok {
+				return nil, fmt.Errorf("layout `%s` of `%s` not found", layout, name)
+			}
+		}
+
+		set, err := parseTemplate(fsys, shared, file, layoutFile, files.local[base])
+		if err != nil {
+			return nil, fmt.Errorf("`%s`: %w", name, err)
+		}
+
+		sets[name] = set
+	}
+
+	return sets, nil
+}
+
+func parseTemplate(fsys fs.FS, shared *template.Template, file, layoutFile string, partials []string) (templateSet, error) {
+	tpl, err := shared.Clone()
+	if err != nil {
+		return templateSet{}, fmt.Errorf("clone: %w", err)
+	}
+
+	entrypoint := path.Base(file)
+
+	var filenames []string
+	if len(layoutFile) != 0 {
+		filenames = append(filenames, layoutFile)
+		entrypoint = path.Base(layoutFile)
+	}
+
+	// Main file comes last for its blocks overriding the layout's default ones
+	filenames = append(append(filenames, partials...), file)
+
+	if _, err = tpl.ParseFS(fsys, filenames...); err != nil {
+		return templateSet{}, err
+	}
+
+	main := tpl.Lookup(entrypoint)
+
+	strict, err := newStrictTemplate(main)
+	if err != nil {
+		return templateSet{}, fmt.Errorf("strict: %w", err)
+	}
+
+	return templateSet{
+		tpl:    main,
+		strict: strict,
+	}, nil
+}
+
+// getTemplateFiles lists layouts, shared partials at root or in the partials directory, and for each template directory its main files, one per variant and locale, and its own partials
+func getTemplateFiles(fsys fs.FS) (templateFiles, error) {
+	files := templateFiles{
+		main:    make(map[string]string),
+		layouts: make(map[string]string),
+		local:   make(map[string][]string),
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return files, fmt.Errorf("read templates directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		filename := entry.Name()
+
+		if !entry.IsDir() {
+			if path.Ext(filename) == templateExtension {
+				files.shared = append(files.shared, filename)
+			}
+
+			continue
+		}
+
+		dirFiles, err := getTemplates(fsys, filename, templateExtension)
+		if err != nil {
+			return files, err
+		}
+
+		switch entry.Name() {
+		case layoutsDirname:
+			for _, file := range dirFiles {
+				files.layouts[templateName(file)] = file
+			}
+
+		case partialsDirname:
+			files.shared = append(files.shared, dirFiles...)
+
+		default:
+			for _, file := range dirFiles {
+				if baseName(templateName(file)) == entry.Name() {
+					files.main[templateName(file)] = file
+				} else {
+					files.local[entry.Name()] = append(files.local[entry.Name()], file)
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// Start watches templates directory for changes, with polling for being compatible with every filesystem
+func (s Service) Start(ctx context.Context) {
+	if s.reloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reloadIfChanged(ctx)
+		}
+	}
+}
+
+func (s Service) reloadIfChanged(ctx context.Context) {
+	fingerprint, err := s.getFingerprint()
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "templates fingerprint", slog.Any("error", err))
+		return
+	}
+
+	if current := s.templates.Load(); current != nil && current.fingerprint == fingerprint {
+		return
+	}
+
+	_ = s.Reload(ctx)
+}
+
+func getTemplates(fsys fs.FS, dir, extension string) ([]string, error) {
+	var templates []string
+
+	err := fs.WalkDir(fsys, dir, func(filename string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path.Ext(filename) == extension {
+			templates = append(templates, filename)
+		}
+
+		return nil
+	})
+
+	return templates, err
+}
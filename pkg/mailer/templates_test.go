@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "hello", "hello.tmpl")
+
+	if err := os.Mkdir(filepath.Dir(templatePath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(templatePath, []byte("Hello {{ .Name }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir})
+
+	if status := instance.ReloadStatus(); len(status.Error) != 0 {
+		t.Fatalf("ReloadStatus() = `%s`, want no error", status.Error)
+	}
+
+	if err := os.WriteFile(templatePath, []byte("Hello {{ .Name "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Reload(context.Background()); err == nil {
+		t.Error("Reload() = nil, want parsing error")
+	}
+
+	if status := instance.ReloadStatus(); len(status.Error) == 0 {
+		t.Error("ReloadStatus() = no error, want parsing error")
+	}
+
+	if got := instance.ListTemplates(); len(got) != 1 || got[0].Name != "hello" {
+		t.Errorf("ListTemplates() = %v, want previous templates", got)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"partials/header.tmpl": `{{ define "header" }}Shared{{ end }}`,
+		"layouts/base.tmpl":    `[{{ template "header" }}] {{ block "content" . }}Nothing{{ end }}`,
+		"hello/hello.tmpl":     `{{ define "content" }}Hello {{ .Name }}{{ end }}`,
+		"hello/meta.json":      `{"layout": "base"}`,
+		"bye/bye.tmpl":         `{{ define "content" }}Bye {{ .Name }}{{ end }}`,
+		"bye/header.tmpl":      `{{ define "header" }}Custom{{ end }}`,
+		"bye/meta.json":        `{"layout": "base"}`,
+		"raw/raw.tmpl":         `{{ template "header" }} {{ .Name }}`,
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir})
+
+	if got := instance.ListTemplates(); len(got) != 3 || got[0].Name != "bye" || got[1].Name != "hello" || got[2].Name != "raw" {
+		t.Errorf("ListTemplates() = %v, want only sendable templates", got)
+	}
+
+	cases := map[string]struct {
+		want string
+	}{
+		"hello": {
+			"[Shared] Hello Bob",
+		},
+		"bye": {
+			"[Custom] Bye Bob",
+		},
+		"raw": {
+			"Shared Bob",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			output, err := instance.Render(context.Background(), model.NewMailRequest().Template(intention).Data(map[string]any{"Name": "Bob"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != testCase.want {
+				t.Errorf("Render() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
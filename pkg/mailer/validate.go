@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var templateErrorPosition = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?:`)
+
+type ValidationError struct {
+	Template string `json:"template"`
+	Fixture  string `json:"fixture,omitempty"`
+	File     string `json:"file,omitempty"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+}
+
+type ValidationReport struct {
+	Errors    []ValidationError `json:"errors,omitempty"`
+	Templates int               `json:"templates"`
+	Fixtures  int               `json:"fixtures"`
+	Valid     bool              `json:"valid"`
+}
+
+func (r *ValidationReport) add(templateName, fixture string, err error) {
+	validationErr := ValidationError{
+		Template: templateName,
+		Fixture:  fixture,
+		Message:  err.Error(),
+	}
+
+	if matches := templateErrorPosition.FindStringSubmatch(validationErr.Message); len(matches) != 0 {
+		validationErr.File = matches[1]
+		validationErr.Line, _ = strconv.Atoi(matches[2])
+	}
+
+	r.Errors = append(r.Errors, validationErr)
+}
+
+// Validate parses every template file independently, then executes each template with its layout and partials against all its fixtures, reporting every error found
+func (s Service) Validate() ValidationReport {
+	fsys, _, err := s.openFS()
+	if err != nil {
+		var report ValidationReport
+		report.add("", "", fmt.Errorf("open: %w", err))
+
+		return report
+	}
+
+	return s.validate(fsys)
+}
+
+// validate checks templates of the filesystem, executing only fixtures of the given ones if any
+func (s Service) validate(fsys fs.FS, names ...string) ValidationReport {
+	var report ValidationReport
+
+	files, err := getTemplates(fsys, ".", templateExtension)
+	if err != nil {
+		report.add("", "", fmt.Errorf("get templates: %w", err))
+		return report
+	}
+
+	for _, file := range files {
+		report.Templates++
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			report.add(templateName(file), "", fmt.Errorf("read: %w", err))
+			continue
+		}
+
+		if _, err := template.New(path.Base(file)).Funcs(s.funcs).Parse(string(content)); err != nil {
+			report.add(templateName(file), "", err)
+		}
+	}
+
+	if len(report.Errors) != 0 {
+		return report
+	}
+
+	loaded, err := s.load(fsys)
+	if err != nil {
+		report.add("", "", err)
+		return report
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(loaded.sets)) {
+		base := baseName(name)
+		if len(names) != 0 && !slices.Contains(names, base) {
+			continue
+		}
+
+		fixtures, err := listFixtures(loaded.fsys, base)
+		if err != nil {
+			continue
+		}
+
+		for _, fixture := range fixtures {
+			report.Fixtures++
+
+			content, err := getFixture(loaded.fsys, base, fixture)
+			if err != nil {
+				report.add(name, fixture, err)
+				continue
+			}
+
+			if err := checkPayload(loaded.schemas[base], content); err != nil {
+				report.add(name, fixture, err)
+				continue
+			}
+
+			if err := loaded.sets[name].tpl.Execute(io.Discard, content); err != nil {
+				report.add(name, fixture, err)
+			}
+		}
+	}
+
+	report.Valid = len(report.Errors) == 0
+
+	return report
+}
+
+func templateName(file string) string {
+	return strings.TrimSuffix(path.Base(file), templateExtension)
+}
@@ -0,0 +1,19 @@
+package mailer
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	instance := newTestService(t, Config{TemplatesDir: "../../templates/"})
+
+	report := instance.Validate()
+
+	for _, validationErr := range report.Errors {
+		t.Errorf("Validate() = %+v", validationErr)
+	}
+
+	if !report.Valid || report.Fixtures == 0 {
+		t.Errorf("Validate() = %+v, want valid report with fixtures", report)
+	}
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const variantSeparator = "@"
+
+// Output is the rendered content of a mail request, with the variant of the template chosen for it, its size checked against Gmail clipping, and its embedded images
+type Output struct {
+	io.Reader
+	Variant string
+	Inlines []model.Inline
+	Size    int
+	Clipped bool
+}
+
+// Mail converts the mail request to the mail to send with the rendered content
+func (o Output) Mail(ctx context.Context, mailRequest model.MailRequest) model.Mail {
+	mail := mailRequest.ConvertToMail(ctx, o.Reader)
+	mail.Variant = o.Variant
+	mail.Inlines = o.Inlines
+
+	return mail
+}
+
+// splitVariant separates template's name from its variant, e.g. `hello@a` gives `hello` and `a`
+func splitVariant(name string) (string, string) {
+	base, variant, _ := strings.Cut(name, variantSeparator)
+	return base, variant
+}
+
+// baseName gives the name of the template's directory, without locale and variant
+func baseName(name string) string {
+	name, _ = splitLocale(name)
+	name, _ = splitVariant(name)
+
+	return name
+}
+
+// chooseVariant picks a variant according to their weights, always the same for the same recipients
+func chooseVariant(templateName string, variants []string, weights map[string]uint, recipients []string) string {
+	var total uint64
+	for _, variant := range variants {
+		total += uint64(variantWeight(weights, variant))
+	}
+
+	if total == 0 {
+		return templateName
+	}
+
+	hash := fnv.New64a()
+	_, _ = io.WriteString(hash, templateName)
+
+	for _, recipient := range slices.Sorted(slices.Values(recipients)) {
+		_, _ = io.WriteString(hash, "|"+strings.ToLower(recipient))
+	}
+
+	point := hash.Sum64() % total
+
+	for _, variant := range variants {
+		weight := uint64(variantWeight(weights, variant))
+		if point < weight {
+			return templateName + variantSeparator + variant
+		}
+
+		point -= weight
+	}
+
+	return templateName
+}
+
+// variantWeight gives the weight declared in metadata, variants being equally weighted by default
+func variantWeight(weights map[string]uint, variant string) uint {
+	if weight, ok := weights[variant]; ok {
+		return weight
+	}
+
+	return 1
+}
+
+func variantAttributes(templateName, variant string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("template", templateName),
+		attribute.String("variant", variant),
+	}
+}
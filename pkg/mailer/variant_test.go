@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestChooseVariant(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		weights    map[string]uint
+		recipients []string
+		want       string
+	}{
+		"no weight": {
+			map[string]uint{"a": 0, "b": 0},
+			[]string{"bob@localhost"},
+			"hello",
+		},
+		"only one": {
+			map[string]uint{"a": 0},
+			[]string{"bob@localhost"},
+			"hello@b",
+		},
+		"deterministic": {
+			nil,
+			[]string{"alice@localhost", "bob@localhost"},
+			chooseVariant("hello", []string{"a", "b"}, nil, []string{"BOB@localhost", "alice@localhost"}),
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := chooseVariant("hello", []string{"a", "b"}, testCase.weights, testCase.recipients); got != testCase.want {
+				t.Errorf("chooseVariant() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRenderVariant(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"hello/hello@a.tmpl":    "A {{ .Name }}",
+		"hello/hello@b.tmpl":    "B {{ .Name }}",
+		"hello/hello@b.fr.tmpl": "B fr {{ .Name }}",
+		"hello/meta.json":       `{"weights": {"a": 0}}`,
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instance := newTestService(t, Config{TemplatesDir: dir})
+
+	if got := instance.ListTemplates(); len(got) != 1 || len(got[0].Variants) != 2 || len(got[0].Locales) != 1 {
+		t.Errorf("ListTemplates() = %+v, want one template with its variants", got)
+	}
+
+	cases := map[string]struct {
+		request     model.MailRequest
+		want        string
+		wantVariant string
+	}{
+		"weighted": {
+			model.NewMailRequest().Template("hello").To("bob@localhost"),
+			"B Bob",
+			"hello@b",
+		},
+		"localized": {
+			model.NewMailRequest().Template("hello").To("bob@localhost").WithLocale("fr"),
+			"B fr Bob",
+			"hello@b",
+		},
+		"forced": {
+			model.NewMailRequest().Template("hello@a").To("bob@localhost"),
+			"A Bob",
+			"hello@a",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := instance.Render(context.Background(), testCase.request.Data(map[string]any{"Name": "Bob"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != testCase.want || output.Variant != testCase.wantVariant {
+				t.Errorf("Render() = (`%s`, `%s`), want (`%s`, `%s`)", got, output.Variant, testCase.want, testCase.wantVariant)
+			}
+		})
+	}
+}
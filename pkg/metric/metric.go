@@ -25,10 +25,11 @@ func Create(meterProvider metric.MeterProvider, name string) {
 	metrics[name] = counter
 }
 
-func Increase(ctx context.Context, name, state string) {
-	if gauge, ok := metrics[name]; ok {
+// Increase adds one to the counter created with the `mailer.` prefix, e.g. `render` for `mailer.render`
+func Increase(ctx context.Context, name, state string, attributes ...attribute.KeyValue) {
+	if gauge, ok := metrics["mailer."+name]; ok {
 		gauge.Add(ctx, 1, metric.WithAttributes(
-			attribute.String("state", state),
+			append(attributes, attribute.String("state", state))...,
 		))
 	}
 }
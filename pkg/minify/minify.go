@@ -0,0 +1,113 @@
+package minify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// preserved are elements whose text is rendered as is
+var preserved = map[atom.Atom]bool{
+	atom.Pre:      true,
+	atom.Textarea: true,
+	atom.Script:   true,
+}
+
+// blocks are elements around which whitespace isn't rendered
+var blocks = map[atom.Atom]bool{
+	atom.Html: true, atom.Head: true, atom.Body: true, atom.Title: true, atom.Meta: true, atom.Link: true, atom.Style: true,
+	atom.Div: true, atom.P: true, atom.Center: true, atom.Br: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.Table: true, atom.Thead: true, atom.Tbody: true, atom.Tfoot: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+}
+
+// HTML collapses whitespace, removing it around block elements, and removes comments, except Outlook's conditional ones. Markup is kept as written.
+func HTML(content []byte) ([]byte, error) {
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+
+	var output bytes.Buffer
+	var depth int
+	var pending bool // whitespace not written yet, depending on the next token
+	var afterBlock bool
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("tokenize: %w", err)
+			}
+
+			return output.Bytes(), nil
+
+		case html.TextToken:
+			raw := tokenizer.Raw()
+
+			if depth > 0 {
+				output.Write(raw)
+				continue
+			}
+
+			fields := strings.Fields(string(raw))
+			if len(fields) == 0 {
+				pending = pending || !afterBlock
+				continue
+			}
+
+			if (pending || isSpace(raw[0])) && !afterBlock {
+				output.WriteByte(' ')
+			}
+
+			output.WriteString(strings.Join(fields, " "))
+
+			pending = isSpace(raw[len(raw)-1])
+			afterBlock = false
+
+		case html.CommentToken:
+			if !isConditional(string(tokenizer.Text())) {
+				continue
+			}
+
+			output.Write(tokenizer.Raw())
+
+		default:
+			raw := append([]byte(nil), tokenizer.Raw()...)
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+
+			if pending && !blocks[tag] {
+				output.WriteByte(' ')
+			}
+
+			pending = false
+			afterBlock = blocks[tag]
+
+			if preserved[tag] {
+				switch tokenType {
+				case html.StartTagToken:
+					depth++
+				case html.EndTagToken:
+					depth = max(depth-1, 0)
+				}
+			}
+
+			output.Write(raw)
+		}
+	}
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\f'
+}
+
+// isConditional checks if comment is interpreted by Outlook, e.g. `<!--[if mso]>...<![endif]-->`
+func isConditional(comment string) bool {
+	return strings.HasPrefix(comment, "[if") || strings.HasPrefix(comment, "<![endif]")
+}
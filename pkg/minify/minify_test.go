@@ -0,0 +1,50 @@
+package minify
+
+import (
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		input string
+		want  string
+	}{
+		"blocks": {
+			"<!doctype html>\n<html>\n  <body>\n    <table>\n      <tr>\n        <td>  Hello\n   world  </td>\n      </tr>\n    </table>\n  </body>\n</html>\n",
+			"<!doctype html><html><body><table><tr><td>Hello world</td></tr></table></body></html>",
+		},
+		"inline": {
+			"<p>Hello <b>dear</b>\n  <i>reader</i> !</p>",
+			"<p>Hello <b>dear</b> <i>reader</i> !</p>",
+		},
+		"comments": {
+			"<div><!-- note --><!--[if mso]><table><tr><td><![endif]--><span>A</span><!--[if !mso]><!--><span>B</span><!--<![endif]--></div>",
+			"<div><!--[if mso]><table><tr><td><![endif]--><span>A</span><!--[if !mso]><!--><span>B</span><!--<![endif]--></div>",
+		},
+		"preserved": {
+			"<div>\n<pre>  a\n    b</pre>\n</div>",
+			"<div><pre>  a\n    b</pre></div>",
+		},
+		"attributes": {
+			`<a   href="https://example.com?a=1&amp;b=2"  CLASS="link">Link</a>`,
+			`<a   href="https://example.com?a=1&amp;b=2"  CLASS="link">Link</a>`,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := HTML([]byte(testCase.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := string(actual); got != testCase.want {
+				t.Errorf("HTML() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
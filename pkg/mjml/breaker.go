@@ -0,0 +1,84 @@
+package mjml
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the converter when it failed too many times in a row
+var ErrCircuitOpen = errors.New("mjml converter unavailable, circuit open")
+
+// breaker opens after consecutive failures, then lets a single call try the converter once the cooldown is elapsed
+type breaker struct {
+	mutex     sync.Mutex
+	openUntil time.Time
+	failures  int
+	threshold int
+	cooldown  time.Duration
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return false
+	}
+
+	// Half-open: other calls fail fast until this one succeeds or the cooldown elapses again
+	b.openUntil = now.Add(b.cooldown)
+
+	return true
+}
+
+func (b *breaker) record(success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+func (b *breaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.failures >= b.threshold && time.Now().Before(b.openUntil)
+}
@@ -0,0 +1,107 @@
+package mjml
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// invalidResponse makes the test server answer with a body that isn't JSON
+const invalidResponse = -1
+
+func TestRenderResilience(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+		wantOpen  bool
+	}{
+		"retried": {
+			[]int{http.StatusBadGateway, http.StatusOK},
+			2,
+			false,
+			false,
+		},
+		"client error": {
+			[]int{http.StatusBadRequest},
+			1,
+			true,
+			false,
+		},
+		"invalid response": {
+			[]int{invalidResponse},
+			1,
+			true,
+			false,
+		},
+		"down": {
+			[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			3,
+			true,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := testCase.statuses[min(int(calls.Add(1))-1, len(testCase.statuses)-1)]
+				if status == invalidResponse {
+					_, _ = w.Write([]byte("<html>"))
+					return
+				}
+
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(mjmlResponse{HTML: "<html></html>"})
+			}))
+			defer server.Close()
+
+			instance, err := New(&Config{URL: server.URL, Timeout: time.Second, Retries: 2, Backoff: time.Millisecond, BreakerThreshold: 1, BreakerCooldown: time.Minute}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			_, err = instance.Render(ctx, "<mjml></mjml>")
+			if gotErr := err != nil; gotErr != testCase.wantErr {
+				t.Errorf("Render() = `%v`, want error %t", err, testCase.wantErr)
+			}
+
+			if got := calls.Load(); got != testCase.wantCalls {
+				t.Errorf("Render() made %d calls, want %d", got, testCase.wantCalls)
+			}
+
+			if err = instance.Ping(ctx); (err != nil) != testCase.wantOpen {
+				t.Errorf("Ping() = `%v`, want open %t", err, testCase.wantOpen)
+			}
+
+			if !testCase.wantOpen {
+				return
+			}
+
+			if _, err = instance.Render(ctx, "<mjml></mjml>"); !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("Render() = `%v`, want `%s`", err, ErrCircuitOpen)
+			}
+
+			if got := calls.Load(); got != testCase.wantCalls {
+				t.Errorf("Render() called the converter while circuit open, %d calls", got)
+			}
+		})
+	}
+}
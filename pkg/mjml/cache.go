@@ -0,0 +1,238 @@
+package mjml
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	mailer_metric "github.com/ViBiOh/mailer/pkg/metric"
+)
+
+const cacheExtension = ".html"
+
+type (
+	bypassKey     struct{}
+	persistentKey struct{}
+)
+
+// WithoutCache makes the conversions done with the context ignore the cache, e.g. for previewing a template being edited
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func isBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// WithPersistence marks the conversions done with the context as template-level content, without personal data, that can be persisted on disk
+func WithPersistence(ctx context.Context) context.Context {
+	return context.WithValue(ctx, persistentKey{}, true)
+}
+
+func isPersistent(ctx context.Context) bool {
+	persistent, _ := ctx.Value(persistentKey{}).(bool)
+	return persistent
+}
+
+type cacheEntry struct {
+	key  string
+	html string
+}
+
+// cache keeps the most recently used conversions in memory, keyed by a hash of their content, and on disk if a directory is given for the persistent ones, until they are unused for maxAge
+type cache struct {
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	dir     string
+	size    int
+	maxAge  time.Duration
+}
+
+func newCache(size int, dir string, maxAge time.Duration) (*cache, error) {
+	if size <= 0 && len(dir) == 0 {
+		return nil, nil
+	}
+
+	output := &cache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		size:    size,
+		dir:     dir,
+		maxAge:  maxAge,
+	}
+
+	if len(dir) != 0 {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create cache directory: %w", err)
+		}
+
+		if err := output.purge(); err != nil {
+			return nil, fmt.Errorf("purge cache directory: %w", err)
+		}
+	}
+
+	return output, nil
+}
+
+// purge removes the files unused for maxAge, including conversions written before only template-level content was persisted
+func (c *cache) purge() error {
+	if c.maxAge <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheExtension) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if c.expired(info.ModTime()) {
+			if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *cache) expired(modTime time.Time) bool {
+	return c.maxAge > 0 && time.Since(modTime) > c.maxAge
+}
+
+func cacheKey(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *cache) get(ctx context.Context, key string) (string, bool) {
+	if html, ok := c.getMemory(key); ok {
+		mailer_metric.Increase(ctx, "mjml_cache", "hit")
+		return html, true
+	}
+
+	if len(c.dir) != 0 && isPersistent(ctx) {
+		if content, ok := c.getDisk(ctx, key); ok {
+			mailer_metric.Increase(ctx, "mjml_cache", "disk")
+			c.setMemory(key, content)
+
+			return content, true
+		}
+	}
+
+	mailer_metric.Increase(ctx, "mjml_cache", "miss")
+
+	return "", false
+}
+
+// getDisk reads the persisted conversion, refreshing its modification time so it's purged only when unused
+func (c *cache) getDisk(ctx context.Context, key string) (string, bool) {
+	filename := c.filename(key)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "stat mjml cache", slog.String("key", key), slog.Any("error", err))
+		}
+
+		return "", false
+	}
+
+	if c.expired(info.ModTime()) {
+		return "", false
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "read mjml cache", slog.String("key", key), slog.Any("error", err))
+		return "", false
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(filename, now, now); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "touch mjml cache", slog.String("key", key), slog.Any("error", err))
+	}
+
+	return string(content), true
+}
+
+// set keeps the conversion in memory, and on disk only if it's persistent, as rendered content contains personal data
+func (c *cache) set(ctx context.Context, key, html string) {
+	c.setMemory(key, html)
+
+	if len(c.dir) == 0 || !isPersistent(ctx) {
+		return
+	}
+
+	filename := c.filename(key)
+	tempFile := filename + ".tmp"
+
+	if err := os.WriteFile(tempFile, []byte(html), 0o600); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "write mjml cache", slog.String("key", key), slog.Any("error", err))
+		return
+	}
+
+	if err := os.Rename(tempFile, filename); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "rename mjml cache", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+func (c *cache) getMemory(key string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	c.order.MoveToFront(element)
+
+	return element.Value.(cacheEntry).html, true
+}
+
+func (c *cache) setMemory(key, html string) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(cacheEntry{key: key, html: html})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).key)
+	}
+}
+
+func (c *cache) filename(key string) string {
+	return filepath.Join(c.dir, key+cacheExtension)
+}
@@ -0,0 +1,135 @@
+package mjml
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	instance, err := newCache(2, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	instance.set(ctx, "first", "1")
+	instance.set(ctx, "second", "2")
+
+	if _, ok := instance.get(ctx, "first"); !ok {
+		t.Error("get(first) = false, want true")
+	}
+
+	instance.set(ctx, "third", "3")
+
+	if _, ok := instance.get(ctx, "second"); ok {
+		t.Error("get(second) = true, want least recently used evicted")
+	}
+
+	if html, ok := instance.get(ctx, "first"); !ok || html != "1" {
+		t.Errorf("get(first) = (`%s`, %t), want (`1`, true)", html, ok)
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(mjmlResponse{HTML: "<html></html>"})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	instance, err := New(&Config{URL: server.URL, CacheSize: 10, CacheDir: dir}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := instance.Render(context.Background(), "<mjml><mj-body></mj-body></mjml>"); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Render() persisted %d rendered conversions, want none", len(entries))
+	}
+
+	ctx := WithPersistence(context.Background())
+
+	for range 2 {
+		if _, err := instance.Render(ctx, "<mjml></mjml>"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Render() called API %d times, want 2", got)
+	}
+
+	if _, err := instance.Render(WithoutCache(ctx), "<mjml></mjml>"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("Render() called API %d times, want cache bypassed", got)
+	}
+
+	restarted, err := New(&Config{URL: server.URL, CacheSize: 10, CacheDir: dir}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if html, err := restarted.Render(ctx, "<mjml></mjml>"); err != nil || html != "<html></html>" {
+		t.Errorf("Render() = (`%s`, %v), want persisted conversion", html, err)
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("Render() called API %d times, want conversion read from disk", got)
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for name, age := range map[string]time.Duration{"old": 48 * time.Hour, "recent": time.Hour} {
+		filename := filepath.Join(dir, name+cacheExtension)
+
+		if err := os.WriteFile(filename, []byte("<html></html>"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(filename, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instance, err := newCache(10, dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithPersistence(context.Background())
+
+	if _, ok := instance.get(ctx, "old"); ok {
+		t.Error("get(old) = true, want purged")
+	}
+
+	if _, ok := instance.get(ctx, "recent"); !ok {
+		t.Error("get(recent) = false, want kept")
+	}
+}
@@ -0,0 +1,397 @@
+package mjml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+func (r *renderer) body(n *node) {
+	width := pixels(r.attr(n, "width"), defaultBodyWidth)
+	background := r.attr(n, "background-color")
+
+	r.write(`<body style="`, style("word-spacing", "normal", "background-color", background), "\">\n")
+
+	if len(r.preview) != 0 {
+		r.write(`<div style="display:none;font-size:1px;color:#ffffff;line-height:1px;max-height:0px;max-width:0px;opacity:0;overflow:hidden;">`, r.preview, "</div>\n")
+	}
+
+	r.write(`<div style="`, style("background-color", background), "\" lang=\"und\" dir=\"auto\">\n")
+
+	for _, child := range n.children {
+		switch child.name {
+		case "mj-section":
+			r.section(child, width)
+		case "mj-raw":
+			r.write(child.content, "\n")
+		}
+	}
+
+	r.write("</div>\n</body>\n")
+}
+
+// check ensures that every component of the body is supported, with the expected parent
+func check(body *node) error {
+	for _, section := range body.children {
+		switch section.name {
+		case "mj-raw":
+			continue
+		case "mj-section":
+		default:
+			return fmt.Errorf("`%s` in mj-body: %w", section.name, errUnsupported)
+		}
+
+		for _, column := range section.children {
+			switch column.name {
+			case "mj-raw":
+				continue
+			case "mj-column":
+			default:
+				return fmt.Errorf("`%s` in mj-section: %w", column.name, errUnsupported)
+			}
+
+			for _, content := range column.children {
+				if _, ok := contents[content.name]; !ok && content.name != "mj-raw" {
+					return fmt.Errorf("`%s` in mj-column: %w", content.name, errUnsupported)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *renderer) section(n *node, width int) {
+	background := r.attr(n, "background-color")
+	padding, horizontal := r.padding(n, width)
+	_, fullWidth := n.attributes["full-width"]
+
+	backgroundStyle := style("background", background, "background-color", background)
+	outerStyle, innerStyle := backgroundStyle, ""
+	if fullWidth {
+		outerStyle, innerStyle = "", backgroundStyle
+
+		r.write(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation" style="`, innerStyle, "width:100%;\"><tbody><tr><td>\n")
+	}
+
+	r.write(`<!--[if mso | IE]><table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation" style="width:`, strconv.Itoa(width), `px;" width="`, strconv.Itoa(width), `"><tr><td style="line-height:0px;font-size:0px;mso-line-height-rule:exactly;"><![endif]-->`, "\n")
+	r.write(`<div style="`, outerStyle, style("border-radius", r.attr(n, "border-radius"), "margin", "0px auto", "max-width", strconv.Itoa(width)+"px"), "\">\n")
+	r.write(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation" style="`, outerStyle, style("border-radius", r.attr(n, "border-radius"), "width", "100%"), "\"><tbody><tr>\n")
+	r.write(`<td style="`, style("border", r.attr(n, "border"), "direction", r.attr(n, "direction"), "font-size", "0px", "text-align", r.attr(n, "text-align")), padding, "\">\n")
+
+	r.columns(n, width-horizontal)
+
+	r.write("</td>\n</tr></tbody></table>\n</div>\n")
+	r.write("<!--[if mso | IE]></td></tr></table><![endif]-->\n")
+
+	if fullWidth {
+		r.write("</td></tr></tbody></table>\n")
+	}
+}
+
+func (r *renderer) columns(section *node, width int) {
+	var count int
+	for _, child := range section.children {
+		if child.name == "mj-column" {
+			count++
+		}
+	}
+
+	r.write("<!--[if mso | IE]><table role=\"presentation\" border=\"0\" cellpadding=\"0\" cellspacing=\"0\"><tr><![endif]-->\n")
+
+	for _, child := range section.children {
+		if child.name == "mj-raw" {
+			r.write(child.content, "\n")
+			continue
+		}
+
+		if child.name != "mj-column" {
+			continue
+		}
+
+		columnWidth, class := r.columnWidth(child, width, count)
+
+		r.write(`<!--[if mso | IE]><td class="" style="vertical-align:`, html.EscapeString(r.attr(child, "vertical-align")), ";width:", strconv.Itoa(columnWidth), "px;\"><![endif]-->\n")
+		r.column(child, columnWidth, class)
+		r.write("<!--[if mso | IE]></td><![endif]-->\n")
+	}
+
+	r.write("<!--[if mso | IE]></tr></table><![endif]-->\n")
+}
+
+// columnWidth gives the width of the column in pixels, for Outlook, and its responsive class, registering its media query
+func (r *renderer) columnWidth(n *node, width, count int) (int, string) {
+	raw := r.attr(n, "width")
+
+	if value, ok := strings.CutSuffix(raw, "px"); ok && len(value) != 0 {
+		pixelWidth := pixels(raw, width)
+		class := "mj-column-px-" + strconv.Itoa(pixelWidth)
+		r.mediaQueries[class] = fmt.Sprintf("width:%dpx !important; max-width:%dpx;", pixelWidth, pixelWidth)
+
+		return pixelWidth, class
+	}
+
+	percent := 100 / float64(count)
+	if value, ok := strings.CutSuffix(raw, "%"); ok {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			percent = parsed
+		}
+	}
+
+	formatted := strconv.FormatFloat(percent, 'f', -1, 64)
+	class := "mj-column-per-" + strings.ReplaceAll(formatted, ".", "-")
+	r.mediaQueries[class] = fmt.Sprintf("width:%s%% !important; max-width:%s%%;", formatted, formatted)
+
+	return int(float64(width) * percent / 100), class
+}
+
+func (r *renderer) column(n *node, width int, class string) {
+	padding, horizontal := r.padding(n, width)
+	verticalAlign := r.attr(n, "vertical-align")
+	background := r.attr(n, "background-color")
+
+	r.write(`<div class="mj-outlook-group-fix `, class, `" style="`, style("font-size", "0px", "text-align", "left", "direction", r.attr(n, "direction"), "display", "inline-block", "vertical-align", verticalAlign, "width", "100%"), "\">\n")
+
+	gutter := len(padding) != 0
+	if gutter {
+		r.write(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" width="100%"><tbody><tr><td style="`, style("background-color", background, "border", r.attr(n, "border"), "border-radius", r.attr(n, "border-radius"), "vertical-align", verticalAlign), padding, "\">\n")
+		background = ""
+	}
+
+	r.write(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="`, style("background-color", background, "vertical-align", verticalAlign), "\" width=\"100%\"><tbody>\n")
+
+	for _, child := range n.children {
+		if child.name == "mj-raw" {
+			r.write(child.content, "\n")
+			continue
+		}
+
+		render, ok := contents[child.name]
+		if !ok {
+			continue
+		}
+
+		childPadding, childHorizontal := r.padding(child, width-horizontal)
+
+		r.write("<tr><td")
+
+		if align := r.attr(child, "align"); len(align) != 0 {
+			r.write(` align="`, html.EscapeString(align), `"`)
+		}
+
+		r.write(` style="`, style("background", r.attr(child, "container-background-color"), "font-size", "0px"), childPadding, "word-break:break-word;\">\n")
+		render(r, child, width-horizontal-childHorizontal)
+		r.write("\n</td></tr>\n")
+	}
+
+	r.write("</tbody></table>\n")
+
+	if gutter {
+		r.write("</td></tr></tbody></table>\n")
+	}
+
+	r.write("</div>\n")
+}
+
+// contents are the components that can be put in a column, rendered for the given width in pixels
+var contents = map[string]func(*renderer, *node, int){
+	"mj-text":    (*renderer).text,
+	"mj-image":   (*renderer).image,
+	"mj-button":  (*renderer).button,
+	"mj-table":   (*renderer).table,
+	"mj-divider": (*renderer).divider,
+	"mj-spacer":  (*renderer).spacer,
+}
+
+func (r *renderer) text(n *node, _ int) {
+	r.write(`<div style="`, style(
+		"font-family", r.attr(n, "font-family"),
+		"font-size", r.attr(n, "font-size"),
+		"font-style", r.attr(n, "font-style"),
+		"font-weight", r.attr(n, "font-weight"),
+		"letter-spacing", r.attr(n, "letter-spacing"),
+		"line-height", r.attr(n, "line-height"),
+		"text-align", r.attr(n, "align"),
+		"text-decoration", r.attr(n, "text-decoration"),
+		"text-transform", r.attr(n, "text-transform"),
+		"color", r.attr(n, "color"),
+		"height", r.attr(n, "height"),
+	), "\">", n.content, "</div>")
+}
+
+func (r *renderer) image(n *node, width int) {
+	imageWidth := min(pixels(r.attr(n, "width"), width), width)
+
+	r.write(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="border-collapse:collapse;border-spacing:0px;"><tbody><tr><td style="width:`, strconv.Itoa(imageWidth), "px;\">\n")
+
+	href := r.attr(n, "href")
+	if len(href) != 0 {
+		r.write(`<a href="`, html.EscapeString(href), `" target="`, html.EscapeString(r.attr(n, "target")), "\">\n")
+	}
+
+	r.write(`<img alt="`, html.EscapeString(r.attr(n, "alt")), `" height="`, html.EscapeString(strings.TrimSuffix(r.attr(n, "height"), "px")), `" src="`, html.EscapeString(r.attr(n, "src")), `" style="`, style(
+		"border", "0",
+		"border-radius", r.attr(n, "border-radius"),
+		"display", "block",
+		"outline", "none",
+		"text-decoration", "none",
+		"height", r.attr(n, "height"),
+		"width", "100%",
+		"font-size", r.attr(n, "font-size"),
+	), `"`)
+
+	if title := r.attr(n, "title"); len(title) != 0 {
+		r.write(` title="`, html.EscapeString(title), `"`)
+	}
+
+	r.write(` width="`, strconv.Itoa(imageWidth), "\" />\n")
+
+	if len(href) != 0 {
+		r.write("</a>\n")
+	}
+
+	r.write("</td></tr></tbody></table>")
+}
+
+func (r *renderer) button(n *node, _ int) {
+	background := r.attr(n, "background-color")
+	innerPadding := r.attr(n, "inner-padding")
+
+	r.write(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="`, style("border-collapse", "separate", "width", r.attr(n, "width"), "line-height", "100%"), "\"><tbody><tr>\n")
+	r.write(`<td align="center" bgcolor="`, html.EscapeString(background), `" role="presentation" style="`, style(
+		"border", r.attr(n, "border"),
+		"border-radius", r.attr(n, "border-radius"),
+		"cursor", "auto",
+		"mso-padding-alt", innerPadding,
+		"background", background,
+	), `" valign="`, html.EscapeString(r.attr(n, "vertical-align")), "\">\n")
+
+	tag := "p"
+	href := r.attr(n, "href")
+	if len(href) != 0 {
+		tag = "a"
+	}
+
+	r.write("<", tag)
+
+	if len(href) != 0 {
+		r.write(` href="`, html.EscapeString(href), `" target="`, html.EscapeString(r.attr(n, "target")), `"`)
+	}
+
+	r.write(` style="`, style(
+		"display", "inline-block",
+		"background", background,
+		"color", r.attr(n, "color"),
+		"font-family", r.attr(n, "font-family"),
+		"font-size", r.attr(n, "font-size"),
+		"font-style", r.attr(n, "font-style"),
+		"font-weight", r.attr(n, "font-weight"),
+		"line-height", r.attr(n, "line-height"),
+		"margin", "0",
+		"text-decoration", r.attr(n, "text-decoration"),
+		"text-transform", r.attr(n, "text-transform"),
+		"padding", innerPadding,
+		"mso-padding-alt", "0px",
+		"border-radius", r.attr(n, "border-radius"),
+	), "\">", n.content, "</", tag, ">\n")
+
+	r.write("</td>\n</tr></tbody></table>")
+}
+
+func (r *renderer) table(n *node, _ int) {
+	r.write(`<table cellpadding="`, html.EscapeString(r.attr(n, "cellpadding")), `" cellspacing="`, html.EscapeString(r.attr(n, "cellspacing")), `" width="`, html.EscapeString(r.attr(n, "width")), `" border="0" style="`, style(
+		"color", r.attr(n, "color"),
+		"font-family", r.attr(n, "font-family"),
+		"font-size", r.attr(n, "font-size"),
+		"line-height", r.attr(n, "line-height"),
+		"table-layout", r.attr(n, "table-layout"),
+		"width", r.attr(n, "width"),
+		"border", r.attr(n, "border"),
+	), "\">\n", n.content, "\n</table>")
+}
+
+func (r *renderer) divider(n *node, width int) {
+	border := fmt.Sprintf("%s %s %s", r.attr(n, "border-style"), r.attr(n, "border-width"), r.attr(n, "border-color"))
+	dividerWidth := r.attr(n, "width")
+
+	margin := "0px auto"
+	switch r.attr(n, "align") {
+	case "left":
+		margin = "0px"
+	case "right":
+		margin = "0px 0px 0px auto"
+	}
+
+	r.write(`<p style="`, style("border-top", border, "font-size", "1px", "margin", margin, "width", dividerWidth), "\"></p>\n")
+
+	outlookWidth := pixels(dividerWidth, width)
+	if percent, ok := strings.CutSuffix(dividerWidth, "%"); ok {
+		if parsed, err := strconv.ParseFloat(percent, 64); err == nil {
+			outlookWidth = int(float64(width) * parsed / 100)
+		}
+	}
+
+	r.write(`<!--[if mso | IE]><table align="center" border="0" cellpadding="0" cellspacing="0" style="`, style("border-top", border, "font-size", "1px", "margin", margin, "width", strconv.Itoa(outlookWidth)+"px"), `" role="presentation" width="`, strconv.Itoa(outlookWidth), `px"><tr><td style="height:0;line-height:0;">&nbsp;</td></tr></table><![endif]-->`)
+}
+
+func (r *renderer) spacer(n *node, _ int) {
+	height := r.attr(n, "height")
+
+	r.write(`<div style="`, style("height", height, "line-height", height), "\">&#8202;</div>")
+}
+
+// padding gives the padding style of the component, and its horizontal padding in pixels
+func (r *renderer) padding(n *node, width int) (string, int) {
+	var output strings.Builder
+	var sides [4]int
+
+	if padding := r.attr(n, "padding"); len(padding) != 0 {
+		output.WriteString(style("padding", padding))
+
+		values := strings.Fields(padding)
+		switch len(values) {
+		case 1:
+			sides = [4]int{pixels(values[0], 0), pixels(values[0], 0), pixels(values[0], 0), pixels(values[0], 0)}
+		case 2, 3:
+			sides[1], sides[3] = pixels(values[1], 0), pixels(values[1], 0)
+		case 4:
+			sides[1], sides[3] = pixels(values[1], 0), pixels(values[3], 0)
+		}
+	}
+
+	for index, side := range []string{"top", "right", "bottom", "left"} {
+		if value := r.attr(n, "padding-"+side); len(value) != 0 {
+			output.WriteString(style("padding-"+side, value))
+			sides[index] = pixels(value, 0)
+		}
+	}
+
+	return output.String(), min(sides[1]+sides[3], width)
+}
+
+// style writes CSS declarations from pairs of property and value, skipping empty values
+func style(pairs ...string) string {
+	var output strings.Builder
+
+	for index := 0; index+1 < len(pairs); index += 2 {
+		if len(pairs[index+1]) == 0 {
+			continue
+		}
+
+		output.WriteString(pairs[index] + ":" + html.EscapeString(pairs[index+1]) + ";")
+	}
+
+	return output.String()
+}
+
+// pixels parses a size in pixels, giving the fallback when it's not one
+func pixels(value string, fallback int) int {
+	parsed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil {
+		return fallback
+	}
+
+	return int(parsed)
+}
@@ -3,8 +3,12 @@ package mjml
 import (
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
@@ -17,52 +21,115 @@ import (
 
 var prefix = []byte("<mjml>")
 
+const (
+	BackendAPI    = "api"
+	BackendNative = "native"
+)
+
 type mjmlRequest struct {
 	Mjml string `json:"mjml"`
 }
 
 type mjmlResponse struct {
-	HTML string `json:"html"`
-	Mjml string `json:"mjml"`
+	HTML   string    `json:"html"`
+	Mjml   string    `json:"mjml"`
+	Errors []Warning `json:"errors"`
 }
 
 type Service struct {
-	tracer trace.Tracer
-	req    request.Request
+	tracer     trace.Tracer
+	cache      *cache
+	breaker    *breaker
+	req        request.Request
+	validation string
+	timeout    time.Duration
+	backoff    time.Duration
+	retries    int
+	native     bool
 }
 
 type Config struct {
-	URL      string
-	Username string
-	Password string
+	Backend          string
+	URL              string
+	Username         string
+	Password         string
+	Validation       string
+	CacheDir         string
+	CacheSize        int
+	CacheMaxAge      time.Duration
+	Timeout          time.Duration
+	Retries          int
+	Backoff          time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 func Flags(fs *flag.FlagSet, prefix string) *Config {
 	var config Config
 
+	flags.New("Backend", "Converter: api for the MJML API at URL, native for the built-in one, supporting core components only").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.Backend, BackendAPI, nil)
 	flags.New("URL", "MJML API Converter URL").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.URL, "https://api.mjml.io/v1/render", nil)
 	flags.New("Username", "Application ID or Basic Auth username").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.Username, "", nil)
 	flags.New("Password", "Secret Key or Basic Auth password").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.Password, "", nil)
+	flags.New("Validation", "Handling of API validation errors: ignore, warn for logging and showing them in preview, or fail the rendering").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.Validation, ValidationWarn, nil)
+	flags.New("CacheSize", "Number of conversions kept in memory, 0 to disable").Prefix(prefix).DocPrefix("mjml").IntVar(fs, &config.CacheSize, 100, nil)
+	flags.New("CacheDir", "Directory persisting precompiled conversions, disabled if empty").Prefix(prefix).DocPrefix("mjml").StringVar(fs, &config.CacheDir, "", nil)
+	flags.New("CacheMaxAge", "Duration a persisted conversion is kept unused, 0 to keep it forever").Prefix(prefix).DocPrefix("mjml").DurationVar(fs, &config.CacheMaxAge, 30*24*time.Hour, nil)
+	flags.New("Timeout", "Timeout of a call to the API").Prefix(prefix).DocPrefix("mjml").DurationVar(fs, &config.Timeout, 10*time.Second, nil)
+	flags.New("Retries", "Retries of a call to the API failing with a server error").Prefix(prefix).DocPrefix("mjml").IntVar(fs, &config.Retries, 2, nil)
+	flags.New("Backoff", "Wait before the first retry, doubled on each one").Prefix(prefix).DocPrefix("mjml").DurationVar(fs, &config.Backoff, 200*time.Millisecond, nil)
+	flags.New("BreakerThreshold", "Consecutive failures before failing fast, 0 to disable").Prefix(prefix).DocPrefix("mjml").IntVar(fs, &config.BreakerThreshold, 5, nil)
+	flags.New("BreakerCooldown", "Duration of failing fast before trying the API again").Prefix(prefix).DocPrefix("mjml").DurationVar(fs, &config.BreakerCooldown, 30*time.Second, nil)
 
 	return &config
 }
 
-func New(config *Config, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) Service {
-	if len(config.URL) == 0 {
-		return Service{}
-	}
+func New(config *Config, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (Service, error) {
+	var service Service
 
-	mailer_metric.Create(meterProvider, "mailer.mjml")
+	switch config.Backend {
+	case BackendNative:
+		service.native = true
+
+	case BackendAPI, "":
+		if len(config.URL) == 0 {
+			return service, nil
+		}
+
+		cache, err := newCache(config.CacheSize, config.CacheDir, config.CacheMaxAge)
+		if err != nil {
+			return service, fmt.Errorf("cache: %w", err)
+		}
+
+		switch config.Validation {
+		case ValidationIgnore, ValidationWarn, ValidationFail:
+			service.validation = config.Validation
+		case "":
+			service.validation = ValidationWarn
+		default:
+			return service, fmt.Errorf("unknown validation `%s`", config.Validation)
+		}
 
-	service := Service{
-		req: request.Post(config.URL).BasicAuth(config.Username, config.Password),
+		service.req = request.Post(config.URL).BasicAuth(config.Username, config.Password)
+		service.cache = cache
+		service.breaker = newBreaker(config.BreakerThreshold, config.BreakerCooldown)
+		service.timeout = config.Timeout
+		service.backoff = config.Backoff
+		service.retries = max(config.Retries, 0)
+
+		mailer_metric.Create(meterProvider, "mailer.mjml_cache")
+
+	default:
+		return service, fmt.Errorf("unknown backend `%s`", config.Backend)
 	}
 
+	mailer_metric.Create(meterProvider, "mailer.mjml")
+
 	if tracerProvider != nil {
 		service.tracer = tracerProvider.Tracer("mjml")
 	}
 
-	return service
+	return service, nil
 }
 
 func IsMJML(content []byte) bool {
@@ -70,7 +137,7 @@ func IsMJML(content []byte) bool {
 }
 
 func (s Service) Enabled() bool {
-	return !s.req.IsZero()
+	return s.native || !s.req.IsZero()
 }
 
 func (s Service) Render(ctx context.Context, template string) (string, error) {
@@ -83,18 +150,130 @@ func (s Service) Render(ctx context.Context, template string) (string, error) {
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "render")
 	defer end(&err)
 
-	resp, err := s.req.JSON(ctx, mjmlRequest{template})
+	if s.native {
+		var output string
+
+		output, err = Convert(template)
+		if err != nil {
+			mailer_metric.Increase(ctx, "mjml", "error")
+			return "", fmt.Errorf("convert mjml template: %w", err)
+		}
+
+		mailer_metric.Increase(ctx, "mjml", "success")
+
+		return output, nil
+	}
+
+	useCache := s.cache != nil && !isBypassed(ctx)
+
+	var key string
+	if useCache {
+		key = cacheKey(template)
+
+		if html, ok := s.cache.get(ctx, key); ok {
+			return html, nil
+		}
+	}
+
+	response, err := s.convert(ctx, template)
 	if err != nil {
 		mailer_metric.Increase(ctx, "mjml", "error")
 		return "", fmt.Errorf("render mjml template: %w", err)
 	}
 
+	mailer_metric.Increase(ctx, "mjml", "success")
+
+	if err = s.handleWarnings(ctx, response.Errors); err != nil {
+		return "", err
+	}
+
+	// Warnings are only reported by the API, caching would hide them on next conversions
+	if useCache && (len(response.Errors) == 0 || s.validation == ValidationIgnore) {
+		s.cache.set(ctx, key, response.HTML)
+	}
+
+	return response.HTML, nil
+}
+
+// Ping reports the converter as unavailable while the circuit is open. It only reads the circuit state, without calling the API, so a converter down since the last conversion is noticed on the next one, not by the readiness probe.
+func (s Service) Ping(_ context.Context) error {
+	if s.breaker.isOpen() {
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
+
+// convert calls the API, retrying with an exponential backoff on server errors, or fails fast when the circuit is open
+func (s Service) convert(ctx context.Context, template string) (mjmlResponse, error) {
+	if !s.breaker.allow() {
+		mailer_metric.Increase(ctx, "mjml", "circuit_open")
+		return mjmlResponse{}, ErrCircuitOpen
+	}
+
+	var response mjmlResponse
+	var err error
+
+	for attempt := range s.retries + 1 {
+		if attempt > 0 {
+			mailer_metric.Increase(ctx, "mjml", "retry")
+
+			select {
+			case <-ctx.Done():
+				return response, errors.Join(err, ctx.Err())
+			case <-time.After(s.backoff << (attempt - 1)):
+			}
+		}
+
+		response, err = s.call(ctx, template)
+		if err == nil || !isServerError(err) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	// A canceled caller tells nothing about the converter
+	if ctx.Err() == nil {
+		s.breaker.record(!isServerError(err))
+	}
+
+	return response, err
+}
+
+func (s Service) call(ctx context.Context, template string) (mjmlResponse, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	resp, err := s.req.JSON(ctx, mjmlRequest{template})
+	if err != nil {
+		return mjmlResponse{}, err
+	}
+
 	response, err := httpjson.Read[mjmlResponse](resp)
 	if err != nil {
-		return "", fmt.Errorf("read mjml response: %w", err)
+		return response, fmt.Errorf("read mjml response: %w", err)
 	}
 
-	mailer_metric.Increase(ctx, "mjml", "success")
+	return response, nil
+}
 
-	return response.HTML, nil
+// isServerError checks if the converter is failing: unreachable, too slow or answering with a 5xx. A client error or an unexpected response means the converter is up, retrying wouldn't change the outcome.
+func isServerError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var reqErr request.Error
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
 }
@@ -0,0 +1,344 @@
+package mjml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	defaultBodyWidth  = 600
+	defaultFontFamily = "Ubuntu, Helvetica, Arial, sans-serif"
+)
+
+var errUnsupported = errors.New("unsupported component")
+
+// endingTags are components whose content is raw HTML instead of other components
+var endingTags = map[string]bool{
+	"mj-text":    true,
+	"mj-button":  true,
+	"mj-table":   true,
+	"mj-raw":     true,
+	"mj-title":   true,
+	"mj-preview": true,
+	"mj-style":   true,
+}
+
+// defaults are the attributes of components when not set, the same as MJML ones
+var defaults = map[string]map[string]string{
+	"mj-body": {
+		"width": "600px",
+	},
+	"mj-section": {
+		"direction":  "ltr",
+		"padding":    "20px 0",
+		"text-align": "center",
+	},
+	"mj-column": {
+		"direction":      "ltr",
+		"vertical-align": "top",
+	},
+	"mj-text": {
+		"align":       "left",
+		"color":       "#000000",
+		"font-family": defaultFontFamily,
+		"font-size":   "13px",
+		"line-height": "1",
+		"padding":     "10px 25px",
+	},
+	"mj-image": {
+		"align":     "center",
+		"font-size": "13px",
+		"height":    "auto",
+		"padding":   "10px 25px",
+		"target":    "_blank",
+	},
+	"mj-button": {
+		"align":            "center",
+		"background-color": "#414141",
+		"border":           "none",
+		"border-radius":    "3px",
+		"color":            "#ffffff",
+		"font-family":      defaultFontFamily,
+		"font-size":        "13px",
+		"font-weight":      "normal",
+		"inner-padding":    "10px 25px",
+		"line-height":      "120%",
+		"padding":          "10px 25px",
+		"target":           "_blank",
+		"text-decoration":  "none",
+		"text-transform":   "none",
+		"vertical-align":   "middle",
+	},
+	"mj-table": {
+		"align":        "left",
+		"border":       "none",
+		"cellpadding":  "0",
+		"cellspacing":  "0",
+		"color":        "#000000",
+		"font-family":  defaultFontFamily,
+		"font-size":    "13px",
+		"line-height":  "22px",
+		"padding":      "10px 25px",
+		"table-layout": "auto",
+		"width":        "100%",
+	},
+	"mj-divider": {
+		"align":        "center",
+		"border-color": "#000000",
+		"border-style": "solid",
+		"border-width": "4px",
+		"padding":      "10px 25px",
+		"width":        "100%",
+	},
+	"mj-spacer": {
+		"height": "20px",
+	},
+}
+
+// node is a component of the MJML document, with its children or its raw content for ending tags
+type node struct {
+	attributes map[string]string
+	name       string
+	content    string
+	children   []*node
+}
+
+func (n *node) child(name string) *node {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Convert converts the MJML document to HTML, in process, for the core components: mj-body, mj-section, mj-column, mj-text, mj-image, mj-button, mj-table, mj-divider, mj-spacer and mj-raw
+func Convert(source string) (string, error) {
+	root, err := parse(source)
+	if err != nil {
+		return "", fmt.Errorf("parse: %w", err)
+	}
+
+	document := root.child("mjml")
+	if document == nil {
+		return "", errors.New("no mjml root")
+	}
+
+	body := document.child("mj-body")
+	if body == nil {
+		return "", errors.New("no mj-body")
+	}
+
+	if err = check(body); err != nil {
+		return "", err
+	}
+
+	r := renderer{
+		mediaQueries: make(map[string]string),
+		overrides:    make(map[string]map[string]string),
+	}
+
+	if head := document.child("mj-head"); head != nil {
+		r.head(head)
+	}
+
+	var output strings.Builder
+	r.output = &output
+
+	r.body(body)
+
+	return r.document(output.String()), nil
+}
+
+// parse builds the tree of components, keeping the content of ending tags as is
+func parse(source string) (*node, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(source))
+
+	root := &node{}
+	stack := []*node{root}
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+
+			if len(stack) != 1 {
+				return nil, fmt.Errorf("unclosed `%s`", stack[len(stack)-1].name)
+			}
+
+			return root, nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			current := newNode(tokenizer)
+
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, current)
+
+			if tokenType == html.SelfClosingTagToken {
+				continue
+			}
+
+			if endingTags[current.name] {
+				content, err := readContent(tokenizer, current.name)
+				if err != nil {
+					return nil, err
+				}
+
+				current.content = content
+
+				continue
+			}
+
+			stack = append(stack, current)
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+
+			if len(stack) == 1 || stack[len(stack)-1].name != string(name) {
+				return nil, fmt.Errorf("unexpected closing `%s`", name)
+			}
+
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+func newNode(tokenizer *html.Tokenizer) *node {
+	name, hasAttributes := tokenizer.TagName()
+
+	output := &node{
+		name:       string(name),
+		attributes: make(map[string]string),
+	}
+
+	for hasAttributes {
+		var key, value []byte
+		key, value, hasAttributes = tokenizer.TagAttr()
+
+		output.attributes[string(key)] = string(value)
+	}
+
+	return output
+}
+
+// readContent reads raw markup until the closing tag of the ending component
+func readContent(tokenizer *html.Tokenizer, name string) (string, error) {
+	var content strings.Builder
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return "", fmt.Errorf("unclosed `%s`", name)
+
+		case html.EndTagToken:
+			if tagName, _ := tokenizer.TagName(); string(tagName) == name {
+				return strings.TrimSpace(content.String()), nil
+			}
+		}
+
+		content.Write(tokenizer.Raw())
+	}
+}
+
+// renderer writes HTML of components, collecting what is needed in the head of the document
+type renderer struct {
+	output       *strings.Builder
+	mediaQueries map[string]string
+	overrides    map[string]map[string]string
+	title        string
+	preview      string
+	styles       []string
+}
+
+func (r *renderer) head(head *node) {
+	for _, child := range head.children {
+		switch child.name {
+		case "mj-title":
+			r.title = child.content
+		case "mj-preview":
+			r.preview = child.content
+		case "mj-style":
+			r.styles = append(r.styles, child.content)
+		case "mj-attributes":
+			for _, override := range child.children {
+				r.overrides[override.name] = override.attributes
+			}
+		}
+	}
+}
+
+// attr gives the attribute of the component, then the one of `mj-attributes` for its tag or for all, then the default one
+func (r *renderer) attr(n *node, name string) string {
+	if value, ok := n.attributes[name]; ok {
+		return value
+	}
+
+	if value, ok := r.overrides[n.name][name]; ok {
+		return value
+	}
+
+	if value, ok := r.overrides["mj-all"][name]; ok {
+		return value
+	}
+
+	return defaults[n.name][name]
+}
+
+func (r *renderer) write(parts ...string) {
+	for _, part := range parts {
+		r.output.WriteString(part)
+	}
+}
+
+func (r *renderer) document(body string) string {
+	var output strings.Builder
+
+	output.WriteString(`<!doctype html>
+<html lang="und" dir="auto" xmlns="http://www.w3.org/1999/xhtml" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office">
+<head>
+<title>` + html.EscapeString(r.title) + `</title>
+<!--[if !mso]><!--><meta http-equiv="X-UA-Compatible" content="IE=edge"><!--<![endif]-->
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<style type="text/css">
+#outlook a { padding:0; }
+body { margin:0;padding:0;-webkit-text-size-adjust:100%;-ms-text-size-adjust:100%; }
+table, td { border-collapse:collapse;mso-table-lspace:0pt;mso-table-rspace:0pt; }
+img { border:0;height:auto;line-height:100%;outline:none;text-decoration:none;-ms-interpolation-mode:bicubic; }
+p { display:block;margin:13px 0; }
+</style>
+<!--[if mso]><noscript><xml><o:OfficeDocumentSettings><o:AllowPNG/><o:PixelsPerInch>96</o:PixelsPerInch></o:OfficeDocumentSettings></xml></noscript><![endif]-->
+<!--[if lte mso 11]><style type="text/css">.mj-outlook-group-fix { width:100% !important; }</style><![endif]-->
+`)
+
+	if len(r.mediaQueries) != 0 {
+		output.WriteString("<style type=\"text/css\">\n@media only screen and (min-width:480px) {\n")
+
+		for _, class := range slices.Sorted(maps.Keys(r.mediaQueries)) {
+			output.WriteString("." + class + " { " + r.mediaQueries[class] + " }\n")
+		}
+
+		output.WriteString("}\n</style>\n")
+	}
+
+	for _, style := range r.styles {
+		output.WriteString("<style type=\"text/css\">" + style + "</style>\n")
+	}
+
+	output.WriteString("</head>\n")
+	output.WriteString(body)
+	output.WriteString("</html>\n")
+
+	return output.String()
+}
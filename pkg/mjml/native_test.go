@@ -0,0 +1,91 @@
+package mjml
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	sources, err := filepath.Glob(filepath.Join("testdata", "*.mjml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range sources {
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			t.Parallel()
+
+			content, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := Convert(string(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(source, ".mjml") + ".html"
+
+			if *update {
+				if err = os.WriteFile(golden, []byte(actual), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != string(expected) {
+				t.Errorf("Convert() differs from `%s`, run with -update for reviewing the diff:\n%s", golden, actual)
+			}
+		})
+	}
+}
+
+func TestConvertError(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		source string
+		want   error
+	}{
+		"unsupported": {
+			"<mjml><mj-body><mj-section><mj-column><mj-carousel></mj-carousel></mj-column></mj-section></mj-body></mjml>",
+			errUnsupported,
+		},
+		"no body": {
+			"<mjml><mj-head></mj-head></mjml>",
+			nil,
+		},
+		"unclosed": {
+			"<mjml><mj-body><mj-section>",
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Convert(testCase.source)
+			if err == nil {
+				t.Fatal("Convert() = nil, want error")
+			}
+
+			if testCase.want != nil && !errors.Is(err, testCase.want) {
+				t.Errorf("Convert() = `%s`, want `%s`", err, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package mjml
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	mailer_metric "github.com/ViBiOh/mailer/pkg/metric"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ValidationIgnore = "ignore"
+	ValidationWarn   = "warn"
+	ValidationFail   = "fail"
+)
+
+// Warning is a validation error reported by the MJML API, the HTML being converted anyway
+type Warning struct {
+	TagName string `json:"tagName"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d, %s: %s", w.Line, w.TagName, w.Message)
+}
+
+// ValidationError is returned when the MJML API reports validation errors and validation is set to fail
+type ValidationError []Warning
+
+func (ve ValidationError) Error() string {
+	messages := make([]string, len(ve))
+	for index, warning := range ve {
+		messages[index] = warning.String()
+	}
+
+	return "invalid mjml: " + strings.Join(messages, ", ")
+}
+
+type collectorKey struct{}
+
+type collector struct {
+	mutex    sync.Mutex
+	warnings []Warning
+}
+
+// CollectWarnings makes the conversions done with the context keep their warnings, given by the returned function, e.g. for showing them in a preview
+func CollectWarnings(ctx context.Context) (context.Context, func() []Warning) {
+	instance := &collector{}
+
+	return context.WithValue(ctx, collectorKey{}, instance), func() []Warning {
+		instance.mutex.Lock()
+		defer instance.mutex.Unlock()
+
+		return instance.warnings
+	}
+}
+
+func (s Service) handleWarnings(ctx context.Context, warnings []Warning) error {
+	if len(warnings) == 0 || s.validation == ValidationIgnore {
+		return nil
+	}
+
+	for _, warning := range warnings {
+		mailer_metric.Increase(ctx, "mjml", "warning", attribute.String("tag", warning.TagName))
+	}
+
+	if s.validation == ValidationFail {
+		return httpModel.WrapInvalid(ValidationError(warnings))
+	}
+
+	slog.LogAttrs(ctx, slog.LevelWarn, "mjml validation", slog.Any("warnings", warnings))
+
+	if instance, ok := ctx.Value(collectorKey{}).(*collector); ok {
+		instance.mutex.Lock()
+		defer instance.mutex.Unlock()
+
+		instance.warnings = append(instance.warnings, warnings...)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package mjml
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRenderValidation(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mjmlResponse{
+			HTML:   "<html></html>",
+			Errors: []Warning{{Line: 3, TagName: "mj-text", Message: "Attribute colour is illegal"}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cases := map[string]struct {
+		validation   string
+		wantWarnings int
+		wantErr      bool
+	}{
+		"ignore": {
+			ValidationIgnore,
+			0,
+			false,
+		},
+		"warn": {
+			ValidationWarn,
+			1,
+			false,
+		},
+		"fail": {
+			ValidationFail,
+			0,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance, err := New(&Config{URL: server.URL, Validation: testCase.validation}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, warnings := CollectWarnings(context.Background())
+
+			_, err = instance.Render(ctx, "<mjml></mjml>")
+
+			var validationErr ValidationError
+			if gotErr := errors.As(err, &validationErr); gotErr != testCase.wantErr {
+				t.Errorf("Render() = `%v`, want validation error %t", err, testCase.wantErr)
+			}
+
+			if got := warnings(); len(got) != testCase.wantWarnings {
+				t.Errorf("Render() collected %v, want %d warnings", got, testCase.wantWarnings)
+			}
+		})
+	}
+}
@@ -2,22 +2,80 @@ package model
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log/slog"
+	"maps"
 	"strings"
 )
 
+// VariantHeader identifies the A/B variant of the template used for rendering the mail
+const VariantHeader = "X-Mailer-Variant"
+
+// DSN describes delivery status notifications requested to the relay, as described in RFC 3461
+type DSN struct {
+	Return     string
+	EnvelopeID string
+	Notify     []string
+}
+
+// IsZero checks if no notification is requested
+func (d DSN) IsZero() bool {
+	return len(d.Notify) == 0 && len(d.Return) == 0 && len(d.EnvelopeID) == 0
+}
+
+// Check checks if current instance is valid
+func (d DSN) Check() error {
+	for _, notify := range d.Notify {
+		switch notify {
+		case "SUCCESS", "FAILURE", "DELAY":
+		case "NEVER":
+			if len(d.Notify) != 1 {
+				return errors.New("dsn notify NEVER can't be combined")
+			}
+		default:
+			return fmt.Errorf("dsn notify `%s` is invalid", notify)
+		}
+	}
+
+	switch d.Return {
+	case "", "HDRS", "FULL":
+	default:
+		return fmt.Errorf("dsn return `%s` is invalid", d.Return)
+	}
+
+	if len(d.EnvelopeID) > 100 {
+		return errors.New("dsn envelope id is too long")
+	}
+
+	for _, char := range d.EnvelopeID {
+		if char < '!' || char > '~' {
+			return errors.New("dsn envelope id contains non-printable characters")
+		}
+	}
+
+	return nil
+}
+
 // MailRequest describes an email to be sent
 type MailRequest struct {
 	Payload    any
+	Headers    map[string]string
+	DSN        DSN
 	Tpl        string
 	FromEmail  string
 	Sender     string
 	Subject    string
+	Category   string
+	Locale     string
+	MessageID  string
+	InReplyTo  string
 	Recipients []string
+	References []string
+	Version    uint
 }
 
 // NewMailRequest create a new email
@@ -53,6 +111,68 @@ func (mr MailRequest) WithSubject(subject string) MailRequest {
 	return mr
 }
 
+// WithLocale set locale, used for choosing template and translations
+func (mr MailRequest) WithLocale(locale string) MailRequest {
+	mr.Locale = locale
+
+	return mr
+}
+
+// WithVersion pins a version of the template managed by API, the active one being used if zero
+func (mr MailRequest) WithVersion(version uint) MailRequest {
+	mr.Version = version
+
+	return mr
+}
+
+// WithCategory set category, used for unsubscribing
+func (mr MailRequest) WithCategory(category string) MailRequest {
+	mr.Category = category
+
+	return mr
+}
+
+// WithDSN set delivery status notifications
+func (mr MailRequest) WithDSN(dsn DSN) MailRequest {
+	mr.DSN = dsn
+
+	return mr
+}
+
+// WithMessageID set message id, generated at sending if empty
+func (mr MailRequest) WithMessageID(messageID string) MailRequest {
+	mr.MessageID = FormatMessageID(messageID)
+
+	return mr
+}
+
+// ReplyTo set the message id this email replies to
+func (mr MailRequest) ReplyTo(messageID string) MailRequest {
+	mr.InReplyTo = FormatMessageID(messageID)
+
+	return mr
+}
+
+// WithReferences add message ids to the thread references
+func (mr MailRequest) WithReferences(messageIDs ...string) MailRequest {
+	for _, messageID := range messageIDs {
+		mr.References = append(mr.References, FormatMessageID(messageID))
+	}
+
+	return mr
+}
+
+// WithHeader add a custom header
+func (mr MailRequest) WithHeader(key, value string) MailRequest {
+	headers := make(map[string]string, len(mr.Headers)+1)
+	maps.Copy(headers, mr.Headers)
+	headers[key] = value
+
+	mr.Headers = headers
+
+	return mr
+}
+
 // To add recipients to list
 func (mr MailRequest) To(recipients ...string) MailRequest {
 	if len(mr.Recipients) == 0 {
@@ -71,12 +191,17 @@ func (mr MailRequest) Data(payload any) MailRequest {
 	return mr
 }
 
-// Check checks if current instance is valid
+// Check checks if current instance is valid, once completed with the metadata of its template
 func (mr MailRequest) Check() error {
 	if len(mr.FromEmail) == 0 {
 		return errors.New("from email is required")
 	}
 
+	return mr.CheckPartial()
+}
+
+// CheckPartial checks the fields that can't be completed with the metadata of the template, e.g. before sending it to the mailer
+func (mr MailRequest) CheckPartial() error {
 	if len(mr.Recipients) == 0 {
 		return errors.New("recipients are required")
 	}
@@ -91,7 +216,17 @@ func (mr MailRequest) Check() error {
 		return errors.New("template name is required")
 	}
 
-	return nil
+	if err := CheckMessageIDs(append([]string{mr.MessageID, mr.InReplyTo}, mr.References...)...); err != nil {
+		return err
+	}
+
+	for key, value := range mr.Headers {
+		if err := CheckHeader(key, value); err != nil {
+			return err
+		}
+	}
+
+	return mr.DSN.Check()
 }
 
 func getSubject(ctx context.Context, subject string, payload any) string {
@@ -118,21 +253,148 @@ func getSubject(ctx context.Context, subject string, payload any) string {
 // ConvertToMail convert mail request to Mail with given content
 func (mr MailRequest) ConvertToMail(ctx context.Context, content io.Reader) Mail {
 	return Mail{
-		From:    mr.FromEmail,
-		Sender:  mr.Sender,
-		Subject: getSubject(ctx, mr.Subject, mr.Payload),
-		Content: content,
-		To:      mr.Recipients,
+		From:       mr.FromEmail,
+		Sender:     mr.Sender,
+		Subject:    getSubject(ctx, mr.Subject, mr.Payload),
+		Category:   mr.Category,
+		DSN:        mr.DSN,
+		MessageID:  FormatMessageID(mr.MessageID),
+		InReplyTo:  FormatMessageID(mr.InReplyTo),
+		References: mr.References,
+		Headers:    mr.Headers,
+		Content:    content,
+		To:         mr.Recipients,
 	}
 }
 
+// Inline is a file embedded in the mail, referenced in its content by `cid:<ContentID>`
+type Inline struct {
+	ContentID   string
+	ContentType string
+	Filename    string
+	Content     []byte
+}
+
 // Mail describe envelope of an email
 type Mail struct {
-	From    string
-	Sender  string
-	Subject string
-	Content io.Reader
-	To      []string
+	Content     io.Reader
+	Headers     map[string]string
+	DSN         DSN
+	From        string
+	Sender      string
+	Subject     string
+	Category    string
+	Unsubscribe string
+	Variant     string
+	MessageID   string
+	InReplyTo   string
+	To          []string
+	References  []string
+	Inlines     []Inline
+}
+
+// CheckHeader checks that header can be written without altering the message structure
+func CheckHeader(key, value string) error {
+	if len(key) == 0 {
+		return errors.New("header name is required")
+	}
+
+	for _, char := range key {
+		if char < '!' || char > '~' || char == ':' {
+			return fmt.Errorf("header name `%s` is invalid", key)
+		}
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("header `%s` contains line break", key)
+	}
+
+	return nil
+}
+
+// CheckMessageIDs checks that message ids can be written in headers without altering the message structure
+func CheckMessageIDs(messageIDs ...string) error {
+	for _, messageID := range messageIDs {
+		if strings.ContainsAny(messageID, "\r\n") {
+			return fmt.Errorf("message id `%s` contains line break", messageID)
+		}
+	}
+
+	return nil
+}
+
+// NewMessageID generates a unique message id for the domain of the given sender
+func NewMessageID(from string) string {
+	domain := "localhost"
+	if _, fromDomain, ok := strings.Cut(from, "@"); ok && len(fromDomain) != 0 {
+		domain = fromDomain
+	}
+
+	return fmt.Sprintf("<%s@%s>", rand.Text(), domain)
+}
+
+// FormatMessageID encloses message id in angle brackets if needed
+func FormatMessageID(messageID string) string {
+	messageID = strings.TrimSpace(messageID)
+	if len(messageID) == 0 || strings.HasPrefix(messageID, "<") {
+		return messageID
+	}
+
+	return "<" + messageID + ">"
+}
+
+// Result describes the outcome of a sending
+type Result struct {
+	MessageID  string   `json:"message_id,omitempty"`
+	Variant    string   `json:"variant,omitempty"`
+	Sent       []string `json:"sent,omitempty"`
+	Suppressed []string `json:"suppressed,omitempty"`
+	Failed     []string `json:"failed,omitempty"`
+}
+
+// RecipientError describes a recipient rejected by the relay
+type RecipientError struct {
+	Err       error
+	Recipient string
+	Code      int
+}
+
+func (re RecipientError) Error() string {
+	return fmt.Sprintf("recipient `%s`: %s", re.Recipient, re.Err)
+}
+
+func (re RecipientError) Unwrap() error {
+	return re.Err
+}
+
+// Permanent checks if rejection is definitive and should not be retried
+func (re RecipientError) Permanent() bool {
+	return re.Code >= 500 && re.Code < 600
+}
+
+// RecipientErrors lists every RecipientError of the error tree, ok being false if it contains any other error, e.g. an unreachable relay
+func RecipientErrors(err error) (output []RecipientError, ok bool) {
+	switch typedErr := err.(type) {
+	case nil:
+		return nil, true
+	case RecipientError:
+		return []RecipientError{typedErr}, true
+	case interface{ Unwrap() []error }:
+		for _, item := range typedErr.Unwrap() {
+			itemErrs, ok := RecipientErrors(item)
+			if !ok {
+				return nil, false
+			}
+
+			output = append(output, itemErrs...)
+		}
+
+		return output, true
+	case interface{ Unwrap() error }:
+		return RecipientErrors(typedErr.Unwrap())
+	default:
+		return nil, false
+	}
 }
 
 // LoggedCloser closes a ressources with handling error
@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -34,10 +35,34 @@ func TestCheck(t *testing.T) {
 			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr"),
 			errors.New("template name is required"),
 		},
+		"invalid dsn notify": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithDSN(DSN{Notify: []string{"NEVER", "FAILURE"}}),
+			errors.New("dsn notify NEVER can't be combined"),
+		},
+		"invalid dsn return": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithDSN(DSN{Return: "BODY"}),
+			errors.New("dsn return `BODY` is invalid"),
+		},
+		"invalid dsn envelope id": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithDSN(DSN{EnvelopeID: "hello world"}),
+			errors.New("dsn envelope id contains non-printable characters"),
+		},
+		"header injection": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithHeader("X-Campaign", "test\r\nBcc: eve@localhost"),
+			errors.New("header `X-Campaign` contains line break"),
+		},
+		"invalid header name": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithHeader("X Campaign:", "test"),
+			errors.New("header name `X Campaign:` is invalid"),
+		},
 		"valid": {
 			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").WithSubject("test").Template("test"),
 			nil,
 		},
+		"valid dsn": {
+			NewMailRequest().From("nobody@localhost.fr").To("john@doe.fr").Template("test").WithDSN(DSN{Notify: []string{"SUCCESS", "FAILURE"}, Return: "HDRS", EnvelopeID: "1234"}),
+			nil,
+		},
 	}
 
 	for intention, testCase := range cases {
@@ -123,3 +148,80 @@ func TestGetSubject(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatMessageID(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		messageID string
+		want      string
+	}{
+		"empty": {
+			"",
+			"",
+		},
+		"bare": {
+			" repository-1234@ketchup.vibioh.fr ",
+			"<repository-1234@ketchup.vibioh.fr>",
+		},
+		"enclosed": {
+			"<repository-1234@ketchup.vibioh.fr>",
+			"<repository-1234@ketchup.vibioh.fr>",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := FormatMessageID(testCase.messageID); got != testCase.want {
+				t.Errorf("FormatMessageID() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRecipientErrors(t *testing.T) {
+	t.Parallel()
+
+	rejected := RecipientError{Recipient: "bob@localhost", Err: errors.New("rejected"), Code: 550}
+
+	cases := map[string]struct {
+		err    error
+		want   int
+		wantOk bool
+	}{
+		"nil": {
+			nil,
+			0,
+			true,
+		},
+		"wrapped": {
+			fmt.Errorf("send: %w", rejected),
+			1,
+			true,
+		},
+		"joined": {
+			errors.Join(rejected, RecipientError{Recipient: "charlie@localhost", Err: errors.New("timeout")}),
+			2,
+			true,
+		},
+		"other": {
+			errors.Join(rejected, errors.New("connection reset")),
+			0,
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := RecipientErrors(testCase.err)
+
+			if len(got) != testCase.want || gotOk != testCase.wantOk {
+				t.Errorf("RecipientErrors() = (%v, %t), want (%d errors, %t)", got, gotOk, testCase.want, testCase.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Schema is a subset of JSON Schema, covering the keywords useful for describing a template payload
+type Schema struct {
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	pattern              *regexp.Regexp
+	Pattern              string   `json:"pattern"`
+	Type                 Types    `json:"type"`
+	Required             []string `json:"required"`
+	Enum                 []any    `json:"enum"`
+}
+
+// Types is either a single type or a list of types
+type Types []string
+
+func (t *Types) UnmarshalJSON(content []byte) error {
+	var single string
+	if err := json.Unmarshal(content, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(content, &multiple); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings: %w", err)
+	}
+
+	*t = multiple
+
+	return nil
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field not matching the schema
+type ValidationError []FieldError
+
+func (ve ValidationError) Error() string {
+	messages := make([]string, len(ve))
+	for index, fieldErr := range ve {
+		messages[index] = fmt.Sprintf("`%s` %s", fieldErr.Field, fieldErr.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+func Parse(content []byte) (*Schema, error) {
+	var output Schema
+	if err := json.Unmarshal(content, &output); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	if err := output.compile(); err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (s *Schema) compile() (err error) {
+	if len(s.Pattern) != 0 {
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("pattern `%s`: %w", s.Pattern, err)
+		}
+	}
+
+	for name, property := range s.Properties {
+		if err = property.compile(); err != nil {
+			return fmt.Errorf("property `%s`: %w", name, err)
+		}
+	}
+
+	if s.Items != nil {
+		if err = s.Items.compile(); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the JSON-like value against the schema and returns a ValidationError listing every mismatch
+func (s *Schema) Validate(value any) error {
+	value, err := normalize(value)
+	if err != nil {
+		return err
+	}
+
+	var errs ValidationError
+	s.validate("$", value, &errs)
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// normalize converts value to the types produced by encoding/json when it comes from Go code, maps and slices being converted too as they can hold any Go value
+func normalize(value any) (any, error) {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return value, nil
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	var output any
+	if err := json.Unmarshal(content, &output); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	return output, nil
+}
+
+func (s *Schema) validate(field string, value any, errs *ValidationError) {
+	add := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) != 0 && !slices.ContainsFunc(s.Type, func(expected string) bool { return isType(value, expected) }) {
+		add("must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+
+	if len(s.Enum) != 0 && !slices.ContainsFunc(s.Enum, func(item any) bool { return reflect.DeepEqual(item, value) }) {
+		add("must be one of %v", s.Enum)
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		s.validateObject(field, typed, errs)
+	case []any:
+		if s.MinItems != nil && len(typed) < *s.MinItems {
+			add("must have at least %d items", *s.MinItems)
+		}
+
+		if s.MaxItems != nil && len(typed) > *s.MaxItems {
+			add("must have at most %d items", *s.MaxItems)
+		}
+
+		if s.Items != nil {
+			for index, item := range typed {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, index), item, errs)
+			}
+		}
+	case string:
+		length := len([]rune(typed))
+
+		if s.MinLength != nil && length < *s.MinLength {
+			add("must have at least %d characters", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add("must have at most %d characters", *s.MaxLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(typed) {
+			add("must match `%s`", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && typed < *s.Minimum {
+			add("must be greater than or equal to %g", *s.Minimum)
+		}
+
+		if s.Maximum != nil && typed > *s.Maximum {
+			add("must be less than or equal to %g", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) validateObject(field string, value map[string]any, errs *ValidationError) {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			*errs = append(*errs, FieldError{Field: field + "." + name, Message: "is required"})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(value)) {
+		if property, ok := s.Properties[name]; ok {
+			property.validate(field+"."+name, value[name], errs)
+		} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			*errs = append(*errs, FieldError{Field: field + "." + name, Message: "is not allowed"})
+		}
+	}
+}
+
+func isType(value any, expected string) bool {
+	switch expected {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	instance, err := Parse([]byte(`{
+  "type": "object",
+  "required": ["Name", "releases"],
+  "additionalProperties": false,
+  "properties": {
+    "Name": {"type": "string", "minLength": 1},
+    "Age": {"type": ["integer", "null"], "minimum": 0},
+    "Kind": {"enum": ["github", "helm"]},
+    "releases": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["url"],
+        "properties": {
+          "url": {"type": "string", "pattern": "^https?://"}
+        }
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		value any
+		want  ValidationError
+	}{
+		"valid": {
+			map[string]any{"Name": "Bob", "Age": 42.0, "Kind": "helm", "releases": []any{map[string]any{"url": "https://vibioh.fr"}}},
+			nil,
+		},
+		"go types": {
+			struct {
+				Name     string           `json:"Name"`
+				Releases []map[string]any `json:"releases"`
+			}{"Bob", []map[string]any{{"url": "https://vibioh.fr"}}},
+			nil,
+		},
+		"go types in map": {
+			map[string]any{"Name": "Bob", "Age": 42, "releases": []map[string]any{{"url": "https://vibioh.fr"}}},
+			nil,
+		},
+		"not an object": {
+			"Bob",
+			ValidationError{{Field: "$", Message: "must be of type object"}},
+		},
+		"fields": {
+			map[string]any{"Name": "", "Age": 4.2, "Kind": "docker", "Nmae": "Bob", "releases": []any{map[string]any{"url": "ftp://vibioh.fr"}, map[string]any{}}},
+			ValidationError{
+				{Field: "$.Age", Message: "must be of type integer or null"},
+				{Field: "$.Kind", Message: "must be one of [github helm]"},
+				{Field: "$.Name", Message: "must have at least 1 characters"},
+				{Field: "$.Nmae", Message: "is not allowed"},
+				{Field: "$.releases[0].url", Message: "must match `^https?://`"},
+				{Field: "$.releases[1].url", Message: "is required"},
+			},
+		},
+		"required": {
+			map[string]any{},
+			ValidationError{
+				{Field: "$.Name", Message: "is required"},
+				{Field: "$.releases", Message: "is required"},
+			},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var got ValidationError
+
+			gotErr := instance.Validate(testCase.value)
+			if gotErr != nil && !errors.As(gotErr, &got) {
+				t.Fatalf("Validate() = `%s`, want ValidationError", gotErr)
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
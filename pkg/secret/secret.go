@@ -0,0 +1,36 @@
+package secret
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+)
+
+// Secret is shared with the callers of admin endpoints, who give it as Bearer token
+type Secret struct {
+	name  string
+	value []byte
+}
+
+// New creates the secret of the named endpoints, e.g. `suppression`
+func New(name, value string) Secret {
+	return Secret{
+		name:  name,
+		value: []byte(value),
+	}
+}
+
+// Authorize checks the token, denying every request if no secret is configured
+func (s Secret) Authorize(token string) error {
+	if len(s.value) == 0 {
+		return httpModel.WrapForbidden(fmt.Errorf("%s endpoints are disabled without secret", s.name))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), s.value) != 1 {
+		return httpModel.WrapUnauthorized(errors.New("invalid secret"))
+	}
+
+	return nil
+}
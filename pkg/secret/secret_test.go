@@ -0,0 +1,44 @@
+package secret
+
+import (
+	"errors"
+	"testing"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+)
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		secret  string
+		token   string
+		wantErr error
+	}{
+		"disabled": {
+			"",
+			"",
+			httpModel.ErrForbidden,
+		},
+		"invalid": {
+			"s3cr3t",
+			"secret",
+			httpModel.ErrUnauthorized,
+		},
+		"valid": {
+			"s3cr3t",
+			"s3cr3t",
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if gotErr := New("test", testCase.secret).Authorize(testCase.token); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("Authorize() = %v, want %v", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+var (
+	errInvalidLine = errors.New("smtp: A line must not contain CR or LF")
+	errInvalidDSN  = errors.New("smtp: invalid DSN parameter")
+)
+
+// mail issues the MAIL command with DSN parameters, which the standard client doesn't allow
+func mail(smtpClient *smtp.Client, from string, dsn model.DSN) error {
+	if dsn.IsZero() {
+		return smtpClient.Mail(from)
+	}
+
+	if strings.ContainsAny(from, "\r\n") {
+		return errInvalidLine
+	}
+
+	command := fmt.Sprintf("MAIL FROM:<%s>", from)
+
+	if ok, _ := smtpClient.Extension("8BITMIME"); ok {
+		command += " BODY=8BITMIME"
+	}
+
+	if ok, _ := smtpClient.Extension("SMTPUTF8"); ok {
+		command += " SMTPUTF8"
+	}
+
+	switch dsn.Return {
+	case "":
+	case "HDRS", "FULL":
+		command += " RET=" + dsn.Return
+	default:
+		return fmt.Errorf("ret %q: %w", dsn.Return, errInvalidDSN)
+	}
+
+	if len(dsn.EnvelopeID) != 0 {
+		command += " ENVID=" + xtext(dsn.EnvelopeID)
+	}
+
+	return cmd(smtpClient, 250, command)
+}
+
+// rcpt issues the RCPT command with DSN parameters, which the standard client doesn't allow
+func rcpt(smtpClient *smtp.Client, to string, dsn model.DSN) error {
+	if len(dsn.Notify) == 0 {
+		return smtpClient.Rcpt(to)
+	}
+
+	if strings.ContainsAny(to, "\r\n") {
+		return errInvalidLine
+	}
+
+	for _, notify := range dsn.Notify {
+		switch notify {
+		case "NEVER", "SUCCESS", "FAILURE", "DELAY":
+		default:
+			return fmt.Errorf("notify %q: %w", notify, errInvalidDSN)
+		}
+	}
+
+	return cmd(smtpClient, 25, fmt.Sprintf("RCPT TO:<%s> NOTIFY=%s ORCPT=rfc822;%s", to, strings.Join(dsn.Notify, ","), xtext(to)))
+}
+
+func cmd(smtpClient *smtp.Client, expectCode int, command string) error {
+	id, err := smtpClient.Text.Cmd("%s", command)
+	if err != nil {
+		return err
+	}
+
+	smtpClient.Text.StartResponse(id)
+	defer smtpClient.Text.EndResponse(id)
+
+	_, _, err = smtpClient.Text.ReadResponse(expectCode)
+
+	return err
+}
+
+// dsnSupported checks if relay supports DSN, requests are dropped otherwise
+func dsnSupported(smtpClient *smtp.Client, dsn model.DSN) model.DSN {
+	if dsn.IsZero() {
+		return dsn
+	}
+
+	if ok, _ := smtpClient.Extension("DSN"); ok {
+		return dsn
+	}
+
+	slog.LogAttrs(context.Background(), slog.LevelWarn, "relay doesn't support DSN extension, sending without notification request")
+
+	return model.DSN{}
+}
+
+// xtext encodes value as described in RFC 3461
+func xtext(value string) string {
+	var builder strings.Builder
+
+	for _, char := range []byte(value) {
+		if char < '!' || char > '~' || char == '+' || char == '=' {
+			fmt.Fprintf(&builder, "+%02X", char)
+		} else {
+			builder.WriteByte(char)
+		}
+	}
+
+	return builder.String()
+}
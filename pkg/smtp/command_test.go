@@ -0,0 +1,249 @@
+package smtp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+// fakeServer answers to every command and records them, rejecting the `rejected@localhost` recipient
+func fakeServer(t *testing.T, conn net.Conn, extensions []string, commands chan<- string) {
+	t.Helper()
+
+	defer close(commands)
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	write := func(line string) {
+		_, _ = writer.WriteString(line + "\r\n")
+		_ = writer.Flush()
+	}
+
+	write("220 localhost ESMTP")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		commands <- line
+
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			write("250-localhost")
+			for _, extension := range extensions {
+				write("250-" + extension)
+			}
+			write("250 HELP")
+		case line == "DATA":
+			write("354 Go ahead")
+
+			for {
+				if content, err := reader.ReadString('\n'); err != nil || content == ".\r\n" {
+					break
+				}
+			}
+
+			write("250 OK")
+		case strings.HasPrefix(line, "RCPT TO:<rejected@localhost>"):
+			write("550 No such user")
+		case line == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+func TestTransactionDSN(t *testing.T) {
+	t.Parallel()
+
+	envelope := Envelope{
+		From: "alice@localhost",
+		To:   []string{"bob@localhost"},
+		DSN: model.DSN{
+			Notify:     []string{"SUCCESS", "FAILURE"},
+			Return:     "HDRS",
+			EnvelopeID: "id+1",
+		},
+	}
+
+	cases := map[string]struct {
+		extensions []string
+		wantMail   string
+		wantRcpt   string
+	}{
+		"supported": {
+			[]string{"DSN"},
+			"MAIL FROM:<alice@localhost> RET=HDRS ENVID=id+2B1",
+			"RCPT TO:<bob@localhost> NOTIFY=SUCCESS,FAILURE ORCPT=rfc822;bob@localhost",
+		},
+		"not supported": {
+			nil,
+			"MAIL FROM:<alice@localhost>",
+			"RCPT TO:<bob@localhost>",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			clientConn, serverConn := net.Pipe()
+			commands := make(chan string, 10)
+
+			go fakeServer(t, serverConn, testCase.extensions, commands)
+
+			smtpClient, err := smtp.NewClient(clientConn, "localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := transaction(smtpClient, envelope, []byte("Subject: Test\r\n\r\nHello\r\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := smtpClient.Quit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for command := range commands {
+				got = append(got, command)
+			}
+
+			if !slices.Contains(got, testCase.wantMail) || !slices.Contains(got, testCase.wantRcpt) {
+				t.Errorf("transaction() = %q, want `%s` and `%s`", got, testCase.wantMail, testCase.wantRcpt)
+			}
+		})
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		envelopes  []Envelope
+		wantFailed []string
+		wantData   int
+	}{
+		"single transaction": {
+			[]Envelope{{From: "alice@localhost", To: []string{"bob@localhost", "rejected@localhost", "charlie@localhost"}}},
+			[]string{"rejected@localhost"},
+			1,
+		},
+		"verp": {
+			[]Envelope{
+				{From: "bounces+bob=localhost@localhost", To: []string{"bob@localhost"}},
+				{From: "bounces+rejected=localhost@localhost", To: []string{"rejected@localhost"}},
+				{From: "bounces+charlie=localhost@localhost", To: []string{"charlie@localhost"}},
+			},
+			[]string{"rejected@localhost"},
+			2,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			clientConn, serverConn := net.Pipe()
+			commands := make(chan string, 20)
+
+			go fakeServer(t, serverConn, nil, commands)
+
+			smtpClient, err := smtp.NewClient(clientConn, "localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotErr := transactions(smtpClient, []byte("Subject: Test\r\n\r\nHello\r\n"), testCase.envelopes)
+
+			if err := smtpClient.Quit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotData int
+			for command := range commands {
+				if command == "DATA" {
+					gotData++
+				}
+			}
+
+			recipientErrs, ok := model.RecipientErrors(gotErr)
+
+			var gotFailed []string
+			for _, recipientErr := range recipientErrs {
+				gotFailed = append(gotFailed, recipientErr.Recipient)
+
+				if !recipientErr.Permanent() {
+					t.Errorf("transactions() = %v, want permanent error", recipientErr)
+				}
+			}
+
+			if !ok || !slices.Equal(gotFailed, testCase.wantFailed) || gotData != testCase.wantData {
+				t.Errorf("transactions() = (%v, %d DATA), want (%v, %d DATA)", gotErr, gotData, testCase.wantFailed, testCase.wantData)
+			}
+		})
+	}
+}
+
+func TestCommandInvalidDSN(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		dsn model.DSN
+	}{
+		"notify injection": {
+			model.DSN{Notify: []string{"SUCCESS\r\nRCPT TO:<eve@localhost>"}},
+		},
+		"unknown notify": {
+			model.DSN{Notify: []string{"ALWAYS"}},
+		},
+		"ret injection": {
+			model.DSN{Return: "HDRS\r\nRCPT TO:<eve@localhost>", Notify: []string{"SUCCESS"}},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			clientConn, serverConn := net.Pipe()
+			commands := make(chan string, 10)
+
+			go fakeServer(t, serverConn, []string{"DSN"}, commands)
+
+			smtpClient, err := smtp.NewClient(clientConn, "localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotErr := transaction(smtpClient, Envelope{From: "alice@localhost", To: []string{"bob@localhost"}, DSN: testCase.dsn}, []byte("Subject: Test\r\n\r\nHello\r\n"))
+
+			if err := smtpClient.Quit(); err != nil {
+				t.Fatal(err)
+			}
+
+			for command := range commands {
+				if strings.Contains(command, "eve@localhost") || command == "DATA" {
+					t.Errorf("transaction() sent `%s`", command)
+				}
+			}
+
+			if !errors.Is(gotErr, errInvalidDSN) {
+				t.Errorf("transaction() = %v, want %v", gotErr, errInvalidDSN)
+			}
+		})
+	}
+}
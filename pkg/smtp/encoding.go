@@ -0,0 +1,35 @@
+package smtp
+
+import (
+	"bytes"
+	"io"
+	"mime/quotedprintable"
+)
+
+// writeQuotedPrintable encodes content in lines of 76 characters at most, a minified HTML being a single line above the 998 characters allowed by RFC 5322
+func writeQuotedPrintable(writer io.Writer, content io.Reader) error {
+	encoder := quotedprintable.NewWriter(writer)
+
+	if _, err := io.Copy(encoder, content); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// EncodedSize gives the size of the HTML content once encoded in the mail
+func EncodedSize(content []byte) int {
+	var counter byteCounter
+
+	_ = writeQuotedPrintable(&counter, bytes.NewReader(content))
+
+	return int(counter)
+}
+
+type byteCounter int
+
+func (bc *byteCounter) Write(content []byte) (int, error) {
+	*bc += byteCounter(len(content))
+
+	return len(content), nil
+}
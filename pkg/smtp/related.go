@@ -0,0 +1,73 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+// writeRelated writes the mail as a multipart/related message, the HTML content being followed by its inline files
+func writeRelated(body *bytes.Buffer, mail model.Mail) error {
+	writer := multipart.NewWriter(body)
+
+	writeHeaders(body, mail, mime.FormatMediaType("multipart/related", map[string]string{
+		"boundary": writer.Boundary(),
+		"type":     "text/html",
+	}), "")
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {htmlContentType},
+		"Content-Transfer-Encoding": {quotedPrintable},
+	})
+	if err != nil {
+		return fmt.Errorf("create content part: %w", err)
+	}
+
+	if err = writeQuotedPrintable(part, mail.Content); err != nil {
+		return fmt.Errorf("read mail content: %w", err)
+	}
+
+	for _, inline := range mail.Inlines {
+		part, err = writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {inline.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-ID":                {"<" + inline.ContentID + ">"},
+			"Content-Disposition":       {mime.FormatMediaType("inline", map[string]string{"filename": inline.Filename})},
+		})
+		if err != nil {
+			return fmt.Errorf("create inline part: %w", err)
+		}
+
+		if err = writeBase64(part, inline.Content); err != nil {
+			return fmt.Errorf("write inline `%s`: %w", inline.Filename, err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("close multipart: %w", err)
+	}
+
+	return nil
+}
+
+// writeBase64 encodes content in lines of 76 characters, as required by RFC 2045
+func writeBase64(writer io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	for len(encoded) > 0 {
+		line := encoded[:min(base64LineLength, len(encoded))]
+		encoded = encoded[len(line):]
+
+		if _, err := io.WriteString(writer, line+"\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
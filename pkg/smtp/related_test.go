@@ -0,0 +1,73 @@
+package smtp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	netMail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestWriteRelated(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+
+	err := writeRelated(&body, model.Mail{
+		From:    "alice@localhost",
+		To:      []string{"bob@localhost"},
+		Content: strings.NewReader(`<img src="cid:logo@mailer">`),
+		Inlines: []model.Inline{{
+			ContentID:   "logo@mailer",
+			ContentType: "image/png",
+			Filename:    "logo.png",
+			Content:     bytes.Repeat([]byte{0x89}, 100),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := netMail.ReadMessage(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" || params["type"] != "text/html" {
+		t.Fatalf("Content-Type = (`%s`, %v, `%v`), want multipart/related of text/html", mediaType, params, err)
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+
+	content, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if html, _ := io.ReadAll(content); string(html) != `<img src="cid:logo@mailer">` {
+		t.Errorf("content = `%s`, want the HTML", html)
+	}
+
+	inline, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := inline.Header.Get("Content-ID"); got != "<logo@mailer>" {
+		t.Errorf("Content-ID = `%s`, want `<logo@mailer>`", got)
+	}
+
+	if got := inline.FileName(); got != "logo.png" {
+		t.Errorf("FileName() = `%s`, want `logo.png`", got)
+	}
+
+	if _, err = reader.NextPart(); !errors.Is(err, io.EOF) {
+		t.Errorf("NextPart() = `%v`, want only two parts", err)
+	}
+}
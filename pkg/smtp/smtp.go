@@ -7,10 +7,13 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"maps"
 	"net/smtp"
+	"net/textproto"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
@@ -20,6 +23,12 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	htmlContentType  = `text/html; charset="utf-8"`
+	quotedPrintable  = "quoted-printable"
+	base64LineLength = 76
+)
+
 var bufferPool = sync.Pool{
 	New: func() any {
 		return bytes.NewBuffer(nil)
@@ -27,17 +36,26 @@ var bufferPool = sync.Pool{
 }
 
 type Service struct {
-	auth    smtp.Auth
-	tracer  trace.Tracer
-	address string
-	host    string
+	auth          smtp.Auth
+	tracer        trace.Tracer
+	address       string
+	host          string
+	bounceAddress string
 }
 
 type Config struct {
-	Address  string
-	Username string
-	Password string
-	Host     string
+	Address       string
+	Username      string
+	Password      string
+	Host          string
+	BounceAddress string
+}
+
+// Envelope describes sender and recipients of a SMTP transaction
+type Envelope struct {
+	From string
+	DSN  model.DSN
+	To   []string
 }
 
 func Flags(fs *flag.FlagSet, prefix string) *Config {
@@ -47,6 +65,7 @@ func Flags(fs *flag.FlagSet, prefix string) *Config {
 	flags.New("Username", "Plain Auth Username").Prefix(prefix).DocPrefix("smtp").StringVar(fs, &config.Username, "", nil)
 	flags.New("Password", "Plain Auth Password").Prefix(prefix).DocPrefix("smtp").StringVar(fs, &config.Password, "", nil)
 	flags.New("Host", "Plain Auth host").Prefix(prefix).DocPrefix("smtp").StringVar(fs, &config.Host, "127.0.0.1", nil)
+	flags.New("BounceAddress", "Bounce address, encoding recipient in Return-Path with VERP (e.g. bounces@vibioh.fr)").Prefix(prefix).DocPrefix("smtp").StringVar(fs, &config.BounceAddress, "", nil)
 
 	return &config
 }
@@ -61,9 +80,10 @@ func New(config *Config, meterProvider metric.MeterProvider, tracerProvider trac
 	mailer_metric.Create(meterProvider, "mailer.smtp")
 
 	service := Service{
-		address: config.Address,
-		auth:    auth,
-		host:    config.Host,
+		address:       config.Address,
+		auth:          auth,
+		host:          config.Host,
+		bounceAddress: config.BounceAddress,
 	}
 
 	if tracerProvider != nil {
@@ -83,30 +103,100 @@ func (s Service) Send(ctx context.Context, mail model.Mail) error {
 	defer bufferPool.Put(body)
 	body.Reset()
 
+	if err = writeMessage(body, mail); err != nil {
+		return err
+	}
+
+	err = SendMail(s.address, s.host, s.auth, body.Bytes(), s.envelopes(mail)...)
+
+	if err != nil {
+		mailer_metric.Increase(ctx, "smtp", "error")
+	} else {
+		mailer_metric.Increase(ctx, "smtp", "success")
+	}
+
+	return err
+}
+
+// writeMessage writes headers and content of the mail, with its inline files if any
+func writeMessage(body *bytes.Buffer, mail model.Mail) error {
+	if len(mail.Inlines) == 0 {
+		writeHeaders(body, mail, htmlContentType, quotedPrintable)
+
+		if err := writeQuotedPrintable(body, mail.Content); err != nil {
+			return fmt.Errorf("read mail content: %w", err)
+		}
+	} else if err := writeRelated(body, mail); err != nil {
+		return err
+	}
+
+	body.WriteString("\r\n")
+
+	return nil
+}
+
+func writeHeaders(body *bytes.Buffer, mail model.Mail, contentType, transferEncoding string) {
+	messageID := mail.MessageID
+	if len(messageID) == 0 {
+		messageID = model.NewMessageID(mail.From)
+	}
+
+	fmt.Fprintf(body, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
 	fmt.Fprintf(body, "From: %s <%s>\r\n", mail.Sender, mail.From)
 	fmt.Fprintf(body, "To: %s\r\n", strings.Join(mail.To, ","))
 	fmt.Fprintf(body, "Subject: %s\r\n", mail.Subject)
-	body.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
-	body.WriteString("\r\n")
 
-	if _, err = io.Copy(body, mail.Content); err != nil {
-		return fmt.Errorf("read mail content: %w", err)
+	if len(mail.InReplyTo) != 0 {
+		fmt.Fprintf(body, "In-Reply-To: %s\r\n", mail.InReplyTo)
+	}
+
+	if len(mail.References) != 0 {
+		fmt.Fprintf(body, "References: %s\r\n", strings.Join(mail.References, " "))
+	}
+
+	if len(mail.Unsubscribe) != 0 {
+		fmt.Fprintf(body, "List-Unsubscribe: <%s>\r\n", mail.Unsubscribe)
+		body.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+
+	if len(mail.Variant) != 0 {
+		fmt.Fprintf(body, "%s: %s\r\n", model.VariantHeader, mail.Variant)
+	}
+
+	body.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(body, "Content-Type: %s\r\n", contentType)
+
+	if len(transferEncoding) != 0 {
+		fmt.Fprintf(body, "Content-Transfer-Encoding: %s\r\n", transferEncoding)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(mail.Headers)) {
+		fmt.Fprintf(body, "%s: %s\r\n", key, mail.Headers[key])
 	}
 
 	body.WriteString("\r\n")
+}
 
-	err = SendMail(s.address, s.host, s.auth, mail.From, mail.To, body.Bytes())
+func (s Service) envelopes(mail model.Mail) []Envelope {
+	if len(s.bounceAddress) == 0 {
+		return []Envelope{{From: mail.From, To: mail.To, DSN: mail.DSN}}
+	}
 
-	if err != nil {
-		mailer_metric.Increase(ctx, "smtp", "error")
-	} else {
-		mailer_metric.Increase(ctx, "smtp", "success")
+	// VERP needs a transaction per recipient for having a distinct Return-Path
+	envelopes := make([]Envelope, len(mail.To))
+	for index, recipient := range mail.To {
+		envelopes[index] = Envelope{
+			From: EncodeVERP(s.bounceAddress, recipient),
+			To:   []string{recipient},
+			DSN:  mail.DSN,
+		}
 	}
 
-	return err
+	return envelopes
 }
 
-func SendMail(addr, host string, auth smtp.Auth, from string, to []string, body []byte) (err error) {
+func SendMail(addr, host string, auth smtp.Auth, body []byte, envelopes ...Envelope) (err error) {
 	smtpConn, err := tls.Dial("tcp", addr, &tls.Config{
 		ServerName: host,
 	})
@@ -133,16 +223,67 @@ func SendMail(addr, host string, auth smtp.Auth, from string, to []string, body
 		}
 	}
 
-	if err = smtpClient.Mail(from); err != nil {
-		return fmt.Errorf("mail: %w", err)
+	if err = transactions(smtpClient, body, envelopes); err != nil {
+		return err
 	}
 
-	for _, recipient := range to {
-		if err = smtpClient.Rcpt(recipient); err != nil {
-			return fmt.Errorf("recipient `%s`: %w", recipient, err)
+	return smtpClient.Quit()
+}
+
+// transactions sends every envelope, going on after a failed one so each recipient is sent at most once
+func transactions(smtpClient *smtp.Client, body []byte, envelopes []Envelope) (err error) {
+	for index, envelope := range envelopes {
+		transactionErr := transaction(smtpClient, envelope, body)
+		if transactionErr == nil {
+			continue
+		}
+
+		err = errors.Join(err, transactionErr)
+
+		if resetErr := smtpClient.Reset(); resetErr != nil {
+			for _, remaining := range envelopes[index+1:] {
+				err = errors.Join(err, recipientsError(remaining.To, fmt.Errorf("reset: %w", resetErr)))
+			}
+
+			return err
+		}
+	}
+
+	return err
+}
+
+// transaction sends the body to the accepted recipients of the envelope, the failures being a RecipientError for each one
+func transaction(smtpClient *smtp.Client, envelope Envelope, body []byte) error {
+	dsn := dsnSupported(smtpClient, envelope.DSN)
+
+	if err := mail(smtpClient, envelope.From, dsn); err != nil {
+		return recipientsError(envelope.To, fmt.Errorf("mail: %w", err))
+	}
+
+	var err error
+	var accepted []string
+
+	for _, recipient := range envelope.To {
+		if rcptErr := rcpt(smtpClient, recipient, dsn); rcptErr != nil {
+			err = errors.Join(err, recipientError(recipient, rcptErr))
+			continue
 		}
+
+		accepted = append(accepted, recipient)
+	}
+
+	if len(accepted) == 0 {
+		return err
+	}
+
+	if dataErr := data(smtpClient, body); dataErr != nil {
+		return errors.Join(err, recipientsError(accepted, dataErr))
 	}
 
+	return err
+}
+
+func data(smtpClient *smtp.Client, body []byte) error {
 	writer, err := smtpClient.Data()
 	if err != nil {
 		return fmt.Errorf("data: %w", err)
@@ -156,5 +297,32 @@ func SendMail(addr, host string, auth smtp.Auth, from string, to []string, body
 		return fmt.Errorf("close: %w", err)
 	}
 
-	return smtpClient.Quit()
+	return nil
+}
+
+// recipientsError fails every recipient for an error not related to them, so they are not suppressed
+func recipientsError(recipients []string, err error) error {
+	errs := make([]error, len(recipients))
+	for index, recipient := range recipients {
+		errs[index] = model.RecipientError{
+			Recipient: recipient,
+			Err:       err,
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func recipientError(recipient string, err error) error {
+	recipientErr := model.RecipientError{
+		Recipient: recipient,
+		Err:       err,
+	}
+
+	var protocolErr *textproto.Error
+	if errors.As(err, &protocolErr) {
+		recipientErr.Code = protocolErr.Code
+	}
+
+	return recipientErr
 }
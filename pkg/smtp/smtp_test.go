@@ -0,0 +1,52 @@
+package smtp
+
+import (
+	"bytes"
+	"io"
+	"mime/quotedprintable"
+	netMail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/mailer/pkg/model"
+)
+
+func TestWriteMessage(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body style="margin:0">` + strings.Repeat(`<p class="text">Hello Bob</p>`, 100) + `</body></html>`
+
+	var body bytes.Buffer
+
+	if err := writeMessage(&body, model.Mail{
+		From:    "alice@localhost",
+		To:      []string{"bob@localhost"},
+		Content: strings.NewReader(html),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for line := range strings.SplitSeq(body.String(), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("writeMessage() line of %d characters, want at most 76", len(line))
+		}
+	}
+
+	message, err := netMail.ReadMessage(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := message.Header.Get("Content-Transfer-Encoding"); got != quotedPrintable {
+		t.Errorf("Content-Transfer-Encoding = `%s`, want `%s`", got, quotedPrintable)
+	}
+
+	content, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.TrimSpace(string(content)); got != html {
+		t.Errorf("content = `%s`, want the HTML", got)
+	}
+}
@@ -0,0 +1,40 @@
+package smtp
+
+import "strings"
+
+// EncodeVERP encodes the recipient in the bounce address, e.g. bounces+bob=example.com@vibioh.fr, as described in Variable Envelope Return Path
+func EncodeVERP(bounceAddress, recipient string) string {
+	local, domain, ok := strings.Cut(bounceAddress, "@")
+	if !ok {
+		return bounceAddress
+	}
+
+	return local + "+" + strings.Replace(recipient, "@", "=", 1) + "@" + domain
+}
+
+// DecodeVERP extracts the recipient encoded in the given address, if it's a VERP of the bounce address
+func DecodeVERP(bounceAddress, address string) (string, bool) {
+	local, domain, ok := strings.Cut(bounceAddress, "@")
+	if !ok {
+		return "", false
+	}
+
+	address = strings.Trim(strings.TrimSpace(address), "<>")
+
+	encoded, ok := strings.CutPrefix(strings.ToLower(address), strings.ToLower(local)+"+")
+	if !ok {
+		return "", false
+	}
+
+	encoded, ok = strings.CutSuffix(encoded, "@"+strings.ToLower(domain))
+	if !ok {
+		return "", false
+	}
+
+	separatorIndex := strings.LastIndex(encoded, "=")
+	if separatorIndex < 1 || separatorIndex == len(encoded)-1 {
+		return "", false
+	}
+
+	return encoded[:separatorIndex] + "@" + encoded[separatorIndex+1:], true
+}
@@ -0,0 +1,45 @@
+package smtp
+
+import "testing"
+
+func TestDecodeVERP(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		address string
+		want    string
+		wantOk  bool
+	}{
+		"encoded": {
+			EncodeVERP("bounces@vibioh.fr", "bob@example.com"),
+			"bob@example.com",
+			true,
+		},
+		"case and brackets": {
+			"<Bounces+bob=example.com@Vibioh.fr>",
+			"bob@example.com",
+			true,
+		},
+		"other domain": {
+			"bounces+bob=example.com@example.com",
+			"",
+			false,
+		},
+		"not encoded": {
+			"bounces@vibioh.fr",
+			"",
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := DecodeVERP("bounces@vibioh.fr", testCase.address)
+			if got != testCase.want || gotOk != testCase.wantOk {
+				t.Errorf("DecodeVERP() = (`%s`, %t), want (`%s`, %t)", got, gotOk, testCase.want, testCase.wantOk)
+			}
+		})
+	}
+}
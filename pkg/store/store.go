@@ -0,0 +1,368 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/flags"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/secret"
+)
+
+const indexFilename = "index.json"
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Version is an immutable revision of a template
+type Version struct {
+	CreatedAt time.Time `json:"created_at"`
+	Number    uint      `json:"version"`
+}
+
+// Template lists the versions of a stored template, the active one being used for rendering
+type Template struct {
+	Name     string    `json:"name"`
+	Versions []Version `json:"versions"`
+	Active   uint      `json:"active,omitempty"`
+}
+
+// Previous gives the version activated before the current one
+func (t Template) Previous() (uint, bool) {
+	var previous uint
+
+	for _, version := range t.Versions {
+		if version.Number < t.Active && version.Number > previous {
+			previous = version.Number
+		}
+	}
+
+	return previous, previous != 0
+}
+
+func (t Template) has(number uint) bool {
+	return slices.ContainsFunc(t.Versions, func(version Version) bool {
+		return version.Number == number
+	})
+}
+
+type Service struct {
+	mutex  *sync.RWMutex
+	dir    string
+	secret secret.Secret
+}
+
+type Config struct {
+	Dir    string
+	Secret string
+}
+
+func Flags(fs *flag.FlagSet, prefix string) *Config {
+	var config Config
+
+	flags.New("Dir", "Directory storing versions of templates managed by API, disabled if empty").Prefix(prefix).DocPrefix("store").StringVar(fs, &config.Dir, "", nil)
+	flags.New("Secret", "Shared secret required as Bearer token on templates management and reload endpoints, disabled if empty").Prefix(prefix).DocPrefix("store").StringVar(fs, &config.Secret, "", nil)
+
+	return &config
+}
+
+func New(config *Config) (Service, error) {
+	service := Service{
+		dir:    config.Dir,
+		secret: secret.New("templates", config.Secret),
+		mutex:  &sync.RWMutex{},
+	}
+
+	if !service.Enabled() {
+		return service, nil
+	}
+
+	if err := os.MkdirAll(service.dir, 0o700); err != nil {
+		return service, fmt.Errorf("create store directory: %w", err)
+	}
+
+	return service, nil
+}
+
+func (s Service) Enabled() bool {
+	return len(s.dir) != 0
+}
+
+// Authorize checks the shared secret of the templates endpoints
+func (s Service) Authorize(token string) error {
+	return s.secret.Authorize(token)
+}
+
+// CheckName validates that a template name can be stored
+func CheckName(name string) error {
+	if !namePattern.MatchString(name) {
+		return httpModel.WrapInvalid(fmt.Errorf("template name `%s` must only contain letters, digits, `_` or `-`", name))
+	}
+
+	return nil
+}
+
+func (s Service) List() ([]Template, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read store directory: %w", err)
+	}
+
+	var output []Template
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		template, err := s.get(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		output = append(output, template)
+	}
+
+	return output, nil
+}
+
+func (s Service) Get(name string) (Template, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.get(name)
+}
+
+func (s Service) get(name string) (Template, error) {
+	template := Template{Name: name}
+
+	if !s.Enabled() || CheckName(name) != nil {
+		return template, fmt.Errorf("template `%s`: %w", name, httpModel.ErrNotFound)
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.dir, name, indexFilename))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return template, fmt.Errorf("template `%s`: %w", name, httpModel.ErrNotFound)
+		}
+
+		return template, fmt.Errorf("read index of `%s`: %w", name, err)
+	}
+
+	if err := json.Unmarshal(content, &template); err != nil {
+		return template, fmt.Errorf("parse index of `%s`: %w", name, err)
+	}
+
+	return template, nil
+}
+
+// Files returns the content of every file of the version
+func (s Service) Files(name string, version uint) (map[string]string, error) {
+	versionFS, err := s.FS(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(map[string]string)
+
+	return output, fs.WalkDir(versionFS, ".", func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(versionFS, filename)
+		if err != nil {
+			return err
+		}
+
+		output[filename] = string(content)
+
+		return nil
+	})
+}
+
+// FS gives the filesystem of the version, with the same content as a template directory
+func (s Service) FS(name string, version uint) (fs.FS, error) {
+	template, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !template.has(version) {
+		return nil, fmt.Errorf("version %d of `%s`: %w", version, name, httpModel.ErrNotFound)
+	}
+
+	return os.DirFS(s.versionDir(name, version)), nil
+}
+
+// Actives gives the active version of every stored template
+func (s Service) Actives() (map[string]uint, error) {
+	templates, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(map[string]uint, len(templates))
+	for _, template := range templates {
+		if template.Active != 0 {
+			output[template.Name] = template.Active
+		}
+	}
+
+	return output, nil
+}
+
+// Create stores files as a new version of the template, not activated yet
+func (s Service) Create(name string, files map[string]string) (Version, error) {
+	var version Version
+
+	if err := CheckName(name); err != nil {
+		return version, err
+	}
+
+	if len(files) == 0 {
+		return version, httpModel.WrapInvalid(errors.New("files are required"))
+	}
+
+	for filename := range files {
+		if !fs.ValidPath(filename) || filename == "." {
+			return version, httpModel.WrapInvalid(fmt.Errorf("file name `%s` is invalid", filename))
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	template, err := s.get(name)
+	if err != nil && !errors.Is(err, httpModel.ErrNotFound) {
+		return version, err
+	}
+
+	version.CreatedAt = time.Now()
+	for _, existing := range template.Versions {
+		version.Number = max(version.Number, existing.Number)
+	}
+	version.Number++
+
+	versionDir := s.versionDir(name, version.Number)
+
+	for filename, content := range files {
+		target := filepath.Join(versionDir, filepath.FromSlash(filename))
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return version, fmt.Errorf("create directory of `%s`: %w", filename, err)
+		}
+
+		if err := os.WriteFile(target, []byte(content), 0o600); err != nil {
+			return version, fmt.Errorf("write `%s`: %w", filename, err)
+		}
+	}
+
+	template.Versions = append(template.Versions, version)
+
+	return version, s.save(template)
+}
+
+// Activate uses the version for rendering the template
+func (s Service) Activate(name string, version uint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	template, err := s.get(name)
+	if err != nil {
+		return err
+	}
+
+	if !template.has(version) {
+		return fmt.Errorf("version %d of `%s`: %w", version, name, httpModel.ErrNotFound)
+	}
+
+	template.Active = version
+
+	return s.save(template)
+}
+
+// RemoveVersion deletes an inactive version of the template
+func (s Service) RemoveVersion(name string, version uint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	template, err := s.get(name)
+	if err != nil {
+		return err
+	}
+
+	if !template.has(version) {
+		return fmt.Errorf("version %d of `%s`: %w", version, name, httpModel.ErrNotFound)
+	}
+
+	if template.Active == version {
+		return httpModel.WrapInvalid(fmt.Errorf("version %d of `%s` is active", version, name))
+	}
+
+	template.Versions = slices.DeleteFunc(template.Versions, func(item Version) bool {
+		return item.Number == version
+	})
+
+	if err := os.RemoveAll(s.versionDir(name, version)); err != nil {
+		return fmt.Errorf("remove version %d of `%s`: %w", version, name, err)
+	}
+
+	return s.save(template)
+}
+
+// Remove deletes the template with all its versions
+func (s Service) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.get(name); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("remove `%s`: %w", name, err)
+	}
+
+	return nil
+}
+
+func (s Service) versionDir(name string, version uint) string {
+	return filepath.Join(s.dir, name, strconv.FormatUint(uint64(version), 10))
+}
+
+func (s Service) save(template Template) error {
+	content, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	indexFile := filepath.Join(s.dir, template.Name, indexFilename)
+
+	tempFile := indexFile + ".tmp"
+	if err := os.WriteFile(tempFile, content, 0o600); err != nil {
+		return fmt.Errorf("write file `%s`: %w", tempFile, err)
+	}
+
+	if err := os.Rename(tempFile, indexFile); err != nil {
+		return fmt.Errorf("rename file `%s`: %w", tempFile, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	instance, err := New(&Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := instance.Create("../hello", map[string]string{"hello.tmpl": "Hello"}); !errors.Is(err, httpModel.ErrInvalid) {
+		t.Errorf("Create() = %v, want invalid name", err)
+	}
+
+	if _, err := instance.Create("hello", map[string]string{"../hello.tmpl": "Hello"}); !errors.Is(err, httpModel.ErrInvalid) {
+		t.Errorf("Create() = %v, want invalid file name", err)
+	}
+
+	for _, content := range []string{"Hello", "Hi"} {
+		version, err := instance.Create("hello", map[string]string{"hello.tmpl": content})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = instance.Activate("hello", version.Number); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	template, err := instance.Get("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(template.Versions) != 2 || template.Active != 2 {
+		t.Errorf("Get() = %+v, want two versions with the last one active", template)
+	}
+
+	if previous, ok := template.Previous(); !ok || previous != 1 {
+		t.Errorf("Previous() = (%d, %t), want (1, true)", previous, ok)
+	}
+
+	if files, err := instance.Files("hello", 1); err != nil || files["hello.tmpl"] != "Hello" {
+		t.Errorf("Files() = (%v, %v), want first content", files, err)
+	}
+
+	if err := instance.RemoveVersion("hello", 2); !errors.Is(err, httpModel.ErrInvalid) {
+		t.Errorf("RemoveVersion() = %v, want active version refused", err)
+	}
+
+	if err := instance.RemoveVersion("hello", 1); err != nil {
+		t.Errorf("RemoveVersion() = %v", err)
+	}
+
+	if actives, err := instance.Actives(); err != nil || actives["hello"] != 2 {
+		t.Errorf("Actives() = (%v, %v), want hello at version 2", actives, err)
+	}
+
+	if err := instance.Remove("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := instance.Get("hello"); !errors.Is(err, httpModel.ErrNotFound) {
+		t.Errorf("Get() = %v, want not found", err)
+	}
+}
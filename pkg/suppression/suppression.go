@@ -0,0 +1,212 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/flags"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/mailer/pkg/jsonfile"
+	"github.com/ViBiOh/mailer/pkg/secret"
+)
+
+const (
+	ReasonBounce      = "bounce"
+	ReasonUnsubscribe = "unsubscribe"
+	ReasonManual      = "manual"
+)
+
+type Entry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+	Category  string    `json:"category,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+type Service struct {
+	entries map[string]Entry
+	mutex   *sync.RWMutex
+	file    string
+	secret  secret.Secret
+}
+
+type Config struct {
+	File   string
+	Secret string
+}
+
+func Flags(fs *flag.FlagSet, prefix string) *Config {
+	var config Config
+
+	flags.New("File", "Suppression list file, kept in memory if empty").Prefix(prefix).DocPrefix("suppression").StringVar(fs, &config.File, "", nil)
+	flags.New("Secret", "Shared secret required as Bearer token on suppression endpoints, disabled if empty").Prefix(prefix).DocPrefix("suppression").StringVar(fs, &config.Secret, "", nil)
+
+	return &config
+}
+
+func New(config *Config) (Service, error) {
+	service := Service{
+		file:    config.File,
+		secret:  secret.New("suppression", config.Secret),
+		entries: make(map[string]Entry),
+		mutex:   &sync.RWMutex{},
+	}
+
+	entries, err := jsonfile.Load[Entry](config.File)
+	if err != nil {
+		return service, fmt.Errorf("load: %w", err)
+	}
+
+	for _, entry := range entries {
+		service.entries[key(entry.Email, entry.Category)] = entry
+	}
+
+	return service, nil
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// key identifies an entry, an empty category suppresses the email for every category
+func key(email, category string) string {
+	if len(category) == 0 {
+		return normalize(email)
+	}
+
+	return normalize(email) + "|" + strings.TrimSpace(category)
+}
+
+// Authorize checks the shared secret of the suppression endpoints
+func (s Service) Authorize(token string) error {
+	return s.secret.Authorize(token)
+}
+
+func (s Service) List() []Entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	output := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		output = append(output, entry)
+	}
+
+	slices.SortFunc(output, func(a, b Entry) int {
+		if diff := strings.Compare(a.Email, b.Email); diff != 0 {
+			return diff
+		}
+
+		return strings.Compare(a.Category, b.Category)
+	})
+
+	return output
+}
+
+func (s Service) Get(email, category string) (Entry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, ok := s.entries[key(email, category)]
+	if !ok {
+		return entry, fmt.Errorf("suppression `%s`: %w", email, httpModel.ErrNotFound)
+	}
+
+	return entry, nil
+}
+
+func (s Service) Add(ctx context.Context, entry Entry) error {
+	entry.Email = normalize(entry.Email)
+	entry.Category = strings.TrimSpace(entry.Category)
+	if len(entry.Email) == 0 {
+		return httpModel.WrapInvalid(errors.New("email is required"))
+	}
+
+	if len(entry.Reason) == 0 {
+		entry.Reason = ReasonManual
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entryKey := key(entry.Email, entry.Category)
+
+	entries := maps.Clone(s.entries)
+	entries[entryKey] = entry
+
+	if err := jsonfile.Save(s.file, slices.Collect(maps.Values(entries))); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+
+	s.entries[entryKey] = entry
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "recipient suppressed", slog.String("email", entry.Email), slog.String("category", entry.Category), slog.String("reason", entry.Reason))
+
+	return nil
+}
+
+func (s Service) Remove(ctx context.Context, email, category string) error {
+	entryKey := key(email, category)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.entries[entryKey]; !ok {
+		return fmt.Errorf("suppression `%s`: %w", email, httpModel.ErrNotFound)
+	}
+
+	entries := maps.Clone(s.entries)
+	delete(entries, entryKey)
+
+	if err := jsonfile.Save(s.file, slices.Collect(maps.Values(entries))); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+
+	delete(s.entries, entryKey)
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "recipient unsuppressed", slog.String("email", email), slog.String("category", category))
+
+	return nil
+}
+
+// Filter splits recipients between the ones we can send to and the suppressed ones, globally or for the given category
+func (s Service) Filter(recipients []string, category string) (allowed, suppressed []string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, recipient := range recipients {
+		if s.isSuppressed(recipient, category) {
+			suppressed = append(suppressed, recipient)
+		} else {
+			allowed = append(allowed, recipient)
+		}
+	}
+
+	return allowed, suppressed
+}
+
+func (s Service) isSuppressed(email, category string) bool {
+	if _, ok := s.entries[key(email, "")]; ok {
+		return true
+	}
+
+	if len(category) == 0 {
+		return false
+	}
+
+	_, ok := s.entries[key(email, category)]
+
+	return ok
+}
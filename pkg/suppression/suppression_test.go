@@ -0,0 +1,127 @@
+package suppression
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	instance, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Add(context.Background(), Entry{Email: " Bob@Localhost "}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Add(context.Background(), Entry{Email: "charlie@localhost", Category: "newsletter"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		category       string
+		recipients     []string
+		wantAllowed    []string
+		wantSuppressed []string
+	}{
+		"empty": {
+			"",
+			nil,
+			nil,
+			nil,
+		},
+		"allowed": {
+			"",
+			[]string{"alice@localhost"},
+			[]string{"alice@localhost"},
+			nil,
+		},
+		"case insensitive": {
+			"",
+			[]string{"alice@localhost", "BOB@localhost"},
+			[]string{"alice@localhost"},
+			[]string{"BOB@localhost"},
+		},
+		"other category": {
+			"release",
+			[]string{"charlie@localhost", "bob@localhost"},
+			[]string{"charlie@localhost"},
+			[]string{"bob@localhost"},
+		},
+		"category": {
+			"newsletter",
+			[]string{"charlie@localhost", "bob@localhost"},
+			nil,
+			[]string{"charlie@localhost", "bob@localhost"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotAllowed, gotSuppressed := instance.Filter(testCase.recipients, testCase.category)
+
+			if !reflect.DeepEqual(gotAllowed, testCase.wantAllowed) || !reflect.DeepEqual(gotSuppressed, testCase.wantSuppressed) {
+				t.Errorf("Filter() = (%v, %v), want (%v, %v)", gotAllowed, gotSuppressed, testCase.wantAllowed, testCase.wantSuppressed)
+			}
+		})
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	t.Parallel()
+
+	config := Config{
+		File: filepath.Join(t.TempDir(), "suppressions.json"),
+	}
+
+	instance, err := New(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Add(context.Background(), Entry{Email: "alice@localhost", Reason: ReasonBounce}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Add(context.Background(), Entry{Email: "bob@localhost"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Remove(context.Background(), "bob@localhost", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := New(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := reloaded.List()
+	if len(got) != 1 || got[0].Email != "alice@localhost" || got[0].Reason != ReasonBounce {
+		t.Errorf("List() = %+v, want only alice@localhost bounce", got)
+	}
+}
+
+func TestAddSaveError(t *testing.T) {
+	t.Parallel()
+
+	instance, err := New(&Config{File: filepath.Join(t.TempDir(), "missing", "suppressions.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Add(context.Background(), Entry{Email: "alice@localhost"}); err == nil {
+		t.Error("Add() = nil, want error")
+	}
+
+	if got := instance.List(); len(got) != 0 {
+		t.Errorf("List() = %+v, want nothing", got)
+	}
+}
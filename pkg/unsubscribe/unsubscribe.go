@@ -0,0 +1,133 @@
+package unsubscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/flags"
+)
+
+const separator = "\x00"
+
+var (
+	ErrInvalidToken = errors.New("invalid unsubscribe token")
+	ErrExpiredToken = errors.New("expired unsubscribe token")
+)
+
+type Service struct {
+	url    string
+	secret []byte
+	maxAge time.Duration
+}
+
+type Config struct {
+	URL    string
+	Secret string
+	MaxAge time.Duration
+}
+
+func Flags(fs *flag.FlagSet, prefix string) *Config {
+	var config Config
+
+	flags.New("URL", "Public URL of the unsubscribe endpoint (e.g. https://mailer.vibioh.fr/unsubscribe)").Prefix(prefix).DocPrefix("unsubscribe").StringVar(fs, &config.URL, "", nil)
+	flags.New("Secret", "Secret for signing unsubscribe tokens").Prefix(prefix).DocPrefix("unsubscribe").StringVar(fs, &config.Secret, "", nil)
+	flags.New("MaxAge", "Maximum age of unsubscribe tokens, 0 to never expire").Prefix(prefix).DocPrefix("unsubscribe").DurationVar(fs, &config.MaxAge, time.Hour*24*365, nil)
+
+	return &config
+}
+
+func New(config *Config) Service {
+	return Service{
+		url:    strings.TrimSpace(config.URL),
+		secret: []byte(config.Secret),
+		maxAge: config.MaxAge,
+	}
+}
+
+func (s Service) Enabled() bool {
+	return len(s.url) != 0 && len(s.secret) != 0
+}
+
+// URL generates the signed unsubscribe URL of the recipient for the given category, keeping the query of the configured URL
+func (s Service) URL(recipient, category string) string {
+	if !s.Enabled() {
+		return ""
+	}
+
+	link, err := url.Parse(s.url)
+	if err != nil {
+		return ""
+	}
+
+	query := link.Query()
+	query.Set("token", s.Token(recipient, category))
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
+
+func (s Service) Token(recipient, category string) string {
+	return s.token(recipient, category, time.Now())
+}
+
+func (s Service) token(recipient, category string, issuedAt time.Time) string {
+	payload := []byte(strings.ToLower(strings.TrimSpace(recipient)) + separator + category + separator + strconv.FormatInt(issuedAt.Unix(), 10))
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Parse checks the token signature and age, and returns the recipient and category it has been issued for
+func (s Service) Parse(token string) (recipient, category string, err error) {
+	if !s.Enabled() {
+		return "", "", ErrInvalidToken
+	}
+
+	rawPayload, rawSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(rawPayload)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(rawSignature)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return "", "", ErrInvalidToken
+	}
+
+	parts := strings.Split(string(payload), separator)
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return "", "", ErrInvalidToken
+	}
+
+	issuedAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	if s.maxAge > 0 && time.Since(time.Unix(issuedAt, 0)) > s.maxAge {
+		return "", "", ErrExpiredToken
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func (s Service) sign(payload []byte) []byte {
+	hash := hmac.New(sha256.New, s.secret)
+	hash.Write(payload)
+
+	return hash.Sum(nil)
+}
@@ -0,0 +1,117 @@
+package unsubscribe
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	instance := New(&Config{URL: "https://mailer.localhost/unsubscribe", Secret: "secret", MaxAge: time.Hour})
+	other := New(&Config{URL: "https://mailer.localhost/unsubscribe", Secret: "other"})
+
+	cases := map[string]struct {
+		token         string
+		wantRecipient string
+		wantCategory  string
+		wantErr       error
+	}{
+		"valid": {
+			instance.Token("Bob@Localhost", "newsletter"),
+			"bob@localhost",
+			"newsletter",
+			nil,
+		},
+		"no category": {
+			instance.Token("bob@localhost", ""),
+			"bob@localhost",
+			"",
+			nil,
+		},
+		"expired": {
+			instance.token("bob@localhost", "newsletter", time.Now().Add(-time.Hour*2)),
+			"",
+			"",
+			ErrExpiredToken,
+		},
+		"malformed": {
+			"bob@localhost",
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		"wrong signature": {
+			other.Token("bob@localhost", "newsletter"),
+			"",
+			"",
+			ErrInvalidToken,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotRecipient, gotCategory, gotErr := instance.Parse(testCase.token)
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("Parse() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+
+			if gotRecipient != testCase.wantRecipient || gotCategory != testCase.wantCategory {
+				t.Errorf("Parse() = (`%s`, `%s`), want (`%s`, `%s`)", gotRecipient, gotCategory, testCase.wantRecipient, testCase.wantCategory)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		url       string
+		wantPath  string
+		wantQuery url.Values
+	}{
+		"simple": {
+			"https://mailer.localhost/unsubscribe",
+			"https://mailer.localhost/unsubscribe",
+			url.Values{},
+		},
+		"with query": {
+			"https://mailer.localhost/unsubscribe?lang=fr",
+			"https://mailer.localhost/unsubscribe",
+			url.Values{"lang": {"fr"}},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := New(&Config{URL: testCase.url, Secret: "secret"})
+
+			link, err := url.Parse(instance.URL("bob@localhost", "newsletter"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query := link.Query()
+			token := query.Get("token")
+			query.Del("token")
+			link.RawQuery = ""
+
+			if link.String() != testCase.wantPath || !reflect.DeepEqual(query, testCase.wantQuery) {
+				t.Errorf("URL() = (`%s`, %v), want (`%s`, %v)", link, query, testCase.wantPath, testCase.wantQuery)
+			}
+
+			if recipient, category, err := instance.Parse(token); err != nil || recipient != "bob@localhost" || category != "newsletter" {
+				t.Errorf("Parse() = (`%s`, `%s`, `%v`), want valid token", recipient, category, err)
+			}
+		})
+	}
+}